@@ -0,0 +1,2 @@
+
+ALTER TABLE course DROP COLUMN capacity;
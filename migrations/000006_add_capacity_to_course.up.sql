@@ -0,0 +1,4 @@
+
+ALTER TABLE course
+ADD COLUMN capacity INT NOT NULL DEFAULT 10
+CONSTRAINT course_capacity_positive CHECK (capacity > 0);
@@ -0,0 +1,2 @@
+
+ALTER TABLE student DROP COLUMN max_courses;
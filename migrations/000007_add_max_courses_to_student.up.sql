@@ -0,0 +1,4 @@
+
+ALTER TABLE student
+ADD COLUMN max_courses INT
+CONSTRAINT student_max_courses_not_negative CHECK (max_courses >= 0);
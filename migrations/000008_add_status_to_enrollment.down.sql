@@ -0,0 +1,18 @@
+
+DELETE FROM enrollment WHERE status NOT IN ('pending', 'active');
+
+DROP INDEX unique_student_course;
+
+ALTER TABLE enrollment
+ADD CONSTRAINT unique_student_course
+UNIQUE (student_id, course_id);
+
+ALTER TABLE enrollment
+DROP COLUMN status,
+DROP COLUMN reason,
+DROP COLUMN created_at,
+DROP COLUMN pending_at,
+DROP COLUMN activated_at,
+DROP COLUMN completed_at,
+DROP COLUMN dropped_at,
+DROP COLUMN withdrawn_at;
@@ -0,0 +1,23 @@
+
+ALTER TABLE enrollment
+ADD COLUMN status TEXT NOT NULL DEFAULT 'active'
+    CONSTRAINT enrollment_status_valid
+    CHECK (status IN ('pending', 'active', 'completed', 'dropped', 'withdrawn')),
+ADD COLUMN reason TEXT,
+ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+ADD COLUMN pending_at TIMESTAMP,
+ADD COLUMN activated_at TIMESTAMP,
+ADD COLUMN completed_at TIMESTAMP,
+ADD COLUMN dropped_at TIMESTAMP,
+ADD COLUMN withdrawn_at TIMESTAMP;
+
+UPDATE enrollment SET activated_at = created_at;
+
+-- A student may enroll again in a course they dropped or completed, so only
+-- the ongoing enrollment has to be unique. The old constraint only exists on
+-- databases that ran the original fix for duplicated enrollments.
+ALTER TABLE enrollment DROP CONSTRAINT IF EXISTS unique_student_course;
+
+CREATE UNIQUE INDEX unique_student_course
+ON enrollment (student_id, course_id)
+WHERE status IN ('pending', 'active');
@@ -0,0 +1,5 @@
+
+ALTER TABLE course
+DROP CONSTRAINT course_enrollment_window_valid,
+DROP COLUMN enrollment_opens_at,
+DROP COLUMN enrollment_closes_at;
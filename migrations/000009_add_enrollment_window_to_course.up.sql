@@ -0,0 +1,6 @@
+
+ALTER TABLE course
+ADD COLUMN enrollment_opens_at TIMESTAMPTZ,
+ADD COLUMN enrollment_closes_at TIMESTAMPTZ,
+ADD CONSTRAINT course_enrollment_window_valid
+    CHECK (enrollment_opens_at IS NULL OR enrollment_closes_at IS NULL OR enrollment_opens_at < enrollment_closes_at);
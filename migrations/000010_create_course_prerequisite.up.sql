@@ -0,0 +1,10 @@
+
+CREATE TABLE course_prerequisite (
+    course_id INT NOT NULL,
+    prerequisite_id INT NOT NULL,
+
+    PRIMARY KEY (course_id, prerequisite_id),
+    FOREIGN KEY (course_id) REFERENCES course(id) ON DELETE CASCADE,
+    FOREIGN KEY (prerequisite_id) REFERENCES course(id) ON DELETE CASCADE,
+    CONSTRAINT course_prerequisite_not_self CHECK (course_id <> prerequisite_id)
+);
@@ -0,0 +1,12 @@
+
+CREATE TABLE course_slot (
+    id SERIAL PRIMARY KEY,
+    course_id INT NOT NULL,
+    weekday SMALLINT NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+
+    FOREIGN KEY (course_id) REFERENCES course(id) ON DELETE CASCADE,
+    CONSTRAINT course_slot_weekday_valid CHECK (weekday BETWEEN 0 AND 6),
+    CONSTRAINT course_slot_time_valid CHECK (start_time < end_time)
+);
@@ -0,0 +1,3 @@
+
+DROP TABLE enrollment_history;
+DROP FUNCTION enrollment_history_append_only;
@@ -0,0 +1,40 @@
+
+CREATE TABLE enrollment_history (
+    id BIGSERIAL PRIMARY KEY,
+    enrollment_id INT NOT NULL,
+    course_id INT NOT NULL,
+    student_id INT NOT NULL,
+    event TEXT NOT NULL
+        CONSTRAINT enrollment_history_event_valid
+        CHECK (event IN ('enroll', 'unenroll', 'transfer', 'status_change')),
+    status TEXT NOT NULL,
+    reason TEXT,
+    actor TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX enrollment_history_course_created_at
+ON enrollment_history (course_id, created_at);
+
+-- History rows are never changed nor removed, not even when the enrollment or
+-- the student they refer to are deleted
+CREATE FUNCTION enrollment_history_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'enrollment_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER enrollment_history_append_only
+BEFORE UPDATE OR DELETE ON enrollment_history
+FOR EACH ROW EXECUTE FUNCTION enrollment_history_append_only();
+
+-- Rebuild what we know of the enrollments made before the history existed
+INSERT INTO enrollment_history (enrollment_id, course_id, student_id, event, status, actor, created_at)
+SELECT id, course_id, student_id, 'enroll', 'active', 'migration', COALESCE(activated_at, created_at)
+FROM enrollment;
+
+INSERT INTO enrollment_history (enrollment_id, course_id, student_id, event, status, reason, actor, created_at)
+SELECT id, course_id, student_id, 'status_change', status, reason, 'migration',
+    COALESCE(completed_at, dropped_at, withdrawn_at, created_at)
+FROM enrollment
+WHERE status NOT IN ('pending', 'active');
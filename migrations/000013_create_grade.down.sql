@@ -0,0 +1,3 @@
+
+DROP TABLE grade_correction;
+DROP TABLE grade;
@@ -0,0 +1,25 @@
+
+CREATE TABLE grade (
+    id SERIAL PRIMARY KEY,
+    enrollment_id INT NOT NULL UNIQUE,
+    value NUMERIC(4, 2) NOT NULL
+        CONSTRAINT grade_value_in_scale CHECK (value BETWEEN 0 AND 10),
+    finalized BOOLEAN NOT NULL DEFAULT FALSE,
+    finalized_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (enrollment_id) REFERENCES enrollment(id) ON DELETE CASCADE
+);
+
+CREATE TABLE grade_correction (
+    id SERIAL PRIMARY KEY,
+    grade_id INT NOT NULL,
+    old_value NUMERIC(4, 2) NOT NULL,
+    new_value NUMERIC(4, 2) NOT NULL,
+    reason TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (grade_id) REFERENCES grade(id) ON DELETE CASCADE
+);
@@ -0,0 +1,21 @@
+CREATE TABLE course_session (
+    id SERIAL PRIMARY KEY,
+    course_id INT NOT NULL,
+    held_at TIMESTAMPTZ NOT NULL,
+    topic TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (course_id) REFERENCES course(id) ON DELETE CASCADE
+);
+
+CREATE TABLE attendance (
+    session_id INT NOT NULL,
+    enrollment_id INT NOT NULL,
+    status TEXT NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (session_id, enrollment_id),
+    FOREIGN KEY (session_id) REFERENCES course_session(id) ON DELETE CASCADE,
+    FOREIGN KEY (enrollment_id) REFERENCES enrollment(id) ON DELETE CASCADE,
+    CONSTRAINT attendance_status_valid CHECK (status IN ('present', 'absent', 'excused'))
+);
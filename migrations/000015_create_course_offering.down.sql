@@ -0,0 +1,19 @@
+ALTER TABLE course
+ADD COLUMN capacity INT NOT NULL DEFAULT 10
+    CONSTRAINT course_capacity_positive CHECK (capacity > 0),
+ADD COLUMN enrollment_opens_at TIMESTAMPTZ,
+ADD COLUMN enrollment_closes_at TIMESTAMPTZ,
+ADD CONSTRAINT course_enrollment_window_valid
+    CHECK (enrollment_opens_at IS NULL OR enrollment_closes_at IS NULL OR enrollment_opens_at < enrollment_closes_at);
+
+UPDATE course c
+SET capacity = o.capacity,
+    enrollment_opens_at = o.enrollment_opens_at,
+    enrollment_closes_at = o.enrollment_closes_at
+FROM course_offering o
+WHERE o.course_id = c.id AND o.is_default;
+
+ALTER TABLE waitlist DROP COLUMN offering_id;
+ALTER TABLE enrollment DROP COLUMN offering_id;
+
+DROP TABLE course_offering;
@@ -0,0 +1,54 @@
+CREATE TABLE course_offering (
+    id SERIAL PRIMARY KEY,
+    course_id INT NOT NULL,
+    name TEXT NOT NULL,
+    starts_on DATE,
+    ends_on DATE,
+    capacity INT NOT NULL,
+    enrollment_opens_at TIMESTAMPTZ,
+    enrollment_closes_at TIMESTAMPTZ,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (course_id) REFERENCES course(id) ON DELETE CASCADE,
+    CONSTRAINT course_offering_capacity_positive CHECK (capacity > 0),
+    CONSTRAINT course_offering_dates_valid CHECK (starts_on <= ends_on),
+    CONSTRAINT course_offering_enrollment_window_valid
+        CHECK (enrollment_opens_at IS NULL OR enrollment_closes_at IS NULL OR enrollment_opens_at < enrollment_closes_at)
+);
+
+-- The default offering takes the enrollments made through the course itself
+CREATE UNIQUE INDEX unique_default_offering
+ON course_offering (course_id)
+WHERE is_default;
+
+-- Every course runs once in its default offering, which takes over the
+-- capacity and the enrollment window of the course
+INSERT INTO course_offering (course_id, name, capacity, enrollment_opens_at, enrollment_closes_at, is_default)
+SELECT id, 'Default', capacity, enrollment_opens_at, enrollment_closes_at, TRUE
+FROM course;
+
+ALTER TABLE enrollment ADD COLUMN offering_id INT REFERENCES course_offering(id);
+
+UPDATE enrollment e
+SET offering_id = o.id
+FROM course_offering o
+WHERE o.course_id = e.course_id AND o.is_default;
+
+ALTER TABLE enrollment ALTER COLUMN offering_id SET NOT NULL;
+
+CREATE INDEX enrollment_offering_id ON enrollment (offering_id);
+
+ALTER TABLE waitlist ADD COLUMN offering_id INT REFERENCES course_offering(id) ON DELETE CASCADE;
+
+UPDATE waitlist w
+SET offering_id = o.id
+FROM course_offering o
+WHERE o.course_id = w.course_id AND o.is_default;
+
+ALTER TABLE waitlist ALTER COLUMN offering_id SET NOT NULL;
+
+ALTER TABLE course
+DROP COLUMN capacity,
+DROP COLUMN enrollment_opens_at,
+DROP COLUMN enrollment_closes_at;
@@ -0,0 +1,18 @@
+CREATE TABLE instructor (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE course_instructor (
+    course_id INT NOT NULL,
+    instructor_id INT NOT NULL,
+    role TEXT NOT NULL,
+    assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (course_id, instructor_id),
+    FOREIGN KEY (course_id) REFERENCES course(id) ON DELETE CASCADE,
+    FOREIGN KEY (instructor_id) REFERENCES instructor(id) ON DELETE CASCADE,
+    CONSTRAINT course_instructor_role_valid CHECK (role IN ('lead', 'assistant'))
+);
+
+CREATE INDEX course_instructor_instructor_id ON course_instructor (instructor_id);
@@ -0,0 +1,3 @@
+ALTER TABLE course_slot DROP COLUMN room_id;
+
+DROP TABLE room;
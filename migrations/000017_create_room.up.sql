@@ -0,0 +1,12 @@
+CREATE TABLE room (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    seats INT NOT NULL,
+
+    CONSTRAINT room_seats_positive CHECK (seats > 0)
+);
+
+-- Deleting a room releases the slots booked in it
+ALTER TABLE course_slot ADD COLUMN room_id INT REFERENCES room(id) ON DELETE SET NULL;
+
+CREATE INDEX course_slot_room_id ON course_slot (room_id);
@@ -0,0 +1,5 @@
+DROP TABLE course_tag;
+
+ALTER TABLE course DROP COLUMN category_id;
+
+DROP TABLE category;
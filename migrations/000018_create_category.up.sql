@@ -0,0 +1,23 @@
+CREATE TABLE category (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    parent_id INT,
+
+    FOREIGN KEY (parent_id) REFERENCES category(id)
+);
+
+CREATE INDEX category_parent_id ON category (parent_id);
+
+ALTER TABLE course ADD COLUMN category_id INT REFERENCES category(id);
+
+CREATE INDEX course_category_id ON course (category_id);
+
+CREATE TABLE course_tag (
+    course_id INT NOT NULL,
+    tag TEXT NOT NULL,
+
+    PRIMARY KEY (course_id, tag),
+    FOREIGN KEY (course_id) REFERENCES course(id) ON DELETE CASCADE
+);
+
+CREATE INDEX course_tag_tag ON course_tag (tag);
@@ -0,0 +1,4 @@
+DROP TABLE invoice;
+
+ALTER TABLE course DROP COLUMN currency;
+ALTER TABLE course DROP COLUMN price;
@@ -0,0 +1,32 @@
+-- Prices are stored in the minor unit of the currency, like cents
+ALTER TABLE course ADD COLUMN price BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE course ADD COLUMN currency TEXT NOT NULL DEFAULT 'BRL';
+ALTER TABLE course ADD CONSTRAINT course_price_valid CHECK (price >= 0);
+ALTER TABLE course ADD CONSTRAINT course_currency_valid CHECK (currency ~ '^[A-Z]{3}$');
+
+CREATE TABLE invoice (
+    id SERIAL PRIMARY KEY,
+    enrollment_id INT NOT NULL UNIQUE,
+    student_id INT NOT NULL,
+    course_id INT NOT NULL,
+    amount BIGINT NOT NULL,
+    currency TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    gateway TEXT NOT NULL,
+    gateway_reference TEXT,
+    payment_url TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    paid_at TIMESTAMPTZ,
+    expired_at TIMESTAMPTZ,
+    canceled_at TIMESTAMPTZ,
+
+    FOREIGN KEY (enrollment_id) REFERENCES enrollment(id) ON DELETE CASCADE,
+    FOREIGN KEY (student_id) REFERENCES student(id) ON DELETE CASCADE,
+    FOREIGN KEY (course_id) REFERENCES course(id) ON DELETE CASCADE,
+    CONSTRAINT invoice_status_valid CHECK (status IN ('open', 'paid', 'expired', 'canceled')),
+    CONSTRAINT unique_gateway_reference UNIQUE (gateway, gateway_reference)
+);
+
+CREATE INDEX invoice_student_id ON invoice (student_id);
+CREATE INDEX invoice_open_expires_at ON invoice (expires_at) WHERE status = 'open';
@@ -0,0 +1,8 @@
+ALTER TABLE invoice DROP COLUMN scholarship_id;
+ALTER TABLE invoice DROP COLUMN coupon_id;
+ALTER TABLE invoice DROP COLUMN discount;
+
+DROP TABLE scholarship;
+DROP TABLE coupon_redemption;
+DROP TABLE coupon_course;
+DROP TABLE coupon;
@@ -0,0 +1,69 @@
+-- Percent discounts hold the percentage in value, fixed discounts hold an
+-- amount in the minor unit of their currency
+CREATE TABLE coupon (
+    id SERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    discount_type TEXT NOT NULL,
+    value BIGINT NOT NULL,
+    currency TEXT,
+    max_redemptions INT,
+    redemptions INT NOT NULL DEFAULT 0,
+    valid_from TIMESTAMPTZ,
+    valid_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT coupon_discount_valid CHECK (
+        (discount_type = 'percent' AND value BETWEEN 1 AND 100 AND currency IS NULL) OR
+        (discount_type = 'fixed' AND value > 0 AND currency ~ '^[A-Z]{3}$')
+    ),
+    CONSTRAINT coupon_redemptions_valid CHECK (
+        redemptions >= 0 AND (max_redemptions IS NULL OR redemptions <= max_redemptions)
+    ),
+    CONSTRAINT coupon_validity_valid CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_from < valid_until)
+);
+
+-- A coupon without courses applies to every course
+CREATE TABLE coupon_course (
+    coupon_id INT NOT NULL,
+    course_id INT NOT NULL,
+
+    PRIMARY KEY (coupon_id, course_id),
+    FOREIGN KEY (coupon_id) REFERENCES coupon(id) ON DELETE CASCADE,
+    FOREIGN KEY (course_id) REFERENCES course(id) ON DELETE CASCADE
+);
+
+CREATE TABLE coupon_redemption (
+    id SERIAL PRIMARY KEY,
+    coupon_id INT NOT NULL,
+    enrollment_id INT NOT NULL UNIQUE,
+    discount BIGINT NOT NULL,
+    redeemed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (coupon_id) REFERENCES coupon(id) ON DELETE CASCADE,
+    FOREIGN KEY (enrollment_id) REFERENCES enrollment(id) ON DELETE CASCADE
+);
+
+CREATE INDEX coupon_redemption_coupon_id ON coupon_redemption (coupon_id);
+
+-- A scholarship without a course applies to every course of the student
+CREATE TABLE scholarship (
+    id SERIAL PRIMARY KEY,
+    student_id INT NOT NULL,
+    course_id INT,
+    percent INT NOT NULL,
+    reason TEXT NOT NULL,
+    valid_from TIMESTAMPTZ,
+    valid_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (student_id) REFERENCES student(id) ON DELETE CASCADE,
+    FOREIGN KEY (course_id) REFERENCES course(id) ON DELETE CASCADE,
+    CONSTRAINT scholarship_percent_valid CHECK (percent BETWEEN 1 AND 100),
+    CONSTRAINT scholarship_validity_valid CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_from < valid_until)
+);
+
+CREATE INDEX scholarship_student_id ON scholarship (student_id);
+
+ALTER TABLE invoice ADD COLUMN discount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE invoice ADD COLUMN coupon_id INT REFERENCES coupon(id) ON DELETE SET NULL;
+ALTER TABLE invoice ADD COLUMN scholarship_id INT REFERENCES scholarship(id) ON DELETE SET NULL;
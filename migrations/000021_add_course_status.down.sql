@@ -0,0 +1,5 @@
+DROP INDEX course_status;
+
+ALTER TABLE course DROP COLUMN archived_at;
+ALTER TABLE course DROP COLUMN published_at;
+ALTER TABLE course DROP COLUMN status;
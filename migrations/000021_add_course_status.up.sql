@@ -0,0 +1,12 @@
+-- Courses created before the publication lifecycle were already public, so
+-- they start out published while new courses start as drafts
+ALTER TABLE course ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+ALTER TABLE course ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE course ADD CONSTRAINT course_status_valid CHECK (status IN ('draft', 'published', 'archived'));
+
+ALTER TABLE course ADD COLUMN published_at TIMESTAMPTZ;
+ALTER TABLE course ADD COLUMN archived_at TIMESTAMPTZ;
+
+UPDATE course SET published_at = NOW();
+
+CREATE INDEX course_status ON course (status);
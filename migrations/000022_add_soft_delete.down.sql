@@ -0,0 +1,5 @@
+DROP INDEX course_deleted_at;
+DROP INDEX student_deleted_at;
+
+ALTER TABLE course DROP COLUMN deleted_at;
+ALTER TABLE student DROP COLUMN deleted_at;
@@ -0,0 +1,7 @@
+-- Deleted students and courses are kept, with the time they were deleted,
+-- until they are purged after the retention period
+ALTER TABLE student ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE course ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX student_deleted_at ON student (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX course_deleted_at ON course (deleted_at) WHERE deleted_at IS NOT NULL;
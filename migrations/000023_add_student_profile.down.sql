@@ -0,0 +1,8 @@
+ALTER TABLE student DROP CONSTRAINT student_cpf_valid;
+ALTER TABLE student DROP CONSTRAINT student_cpf_key;
+ALTER TABLE student DROP CONSTRAINT student_email_key;
+
+ALTER TABLE student DROP COLUMN cpf;
+ALTER TABLE student DROP COLUMN birth_date;
+ALTER TABLE student DROP COLUMN phone;
+ALTER TABLE student DROP COLUMN email;
@@ -0,0 +1,11 @@
+-- Students created before the profile existed have none of these, so they are
+-- all optional. Emails are stored lowercased and CPFs as their 11 digits, so
+-- the unique constraints can compare them as they are.
+ALTER TABLE student ADD COLUMN email TEXT;
+ALTER TABLE student ADD COLUMN phone TEXT;
+ALTER TABLE student ADD COLUMN birth_date DATE;
+ALTER TABLE student ADD COLUMN cpf TEXT;
+
+ALTER TABLE student ADD CONSTRAINT student_email_key UNIQUE (email);
+ALTER TABLE student ADD CONSTRAINT student_cpf_key UNIQUE (cpf);
+ALTER TABLE student ADD CONSTRAINT student_cpf_valid CHECK (cpf ~ '^[0-9]{11}$');
@@ -0,0 +1 @@
+ALTER TABLE course_session DROP COLUMN offering_id;
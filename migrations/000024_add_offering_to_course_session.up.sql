@@ -0,0 +1,12 @@
+-- Every offering runs its own classes. The sessions held so far go to the
+-- default offering, which took every enrollment before offerings existed.
+ALTER TABLE course_session ADD COLUMN offering_id INT REFERENCES course_offering(id) ON DELETE CASCADE;
+
+UPDATE course_session s
+SET offering_id = o.id
+FROM course_offering o
+WHERE o.course_id = s.course_id AND o.is_default;
+
+ALTER TABLE course_session ALTER COLUMN offering_id SET NOT NULL;
+
+CREATE INDEX course_session_offering_id ON course_session (offering_id);
@@ -0,0 +1,16 @@
+-- Calls to the payment gateway are recorded along with the change that needs
+-- them and made once it commits, so no lock is held while the gateway answers
+CREATE TABLE payment_task (
+    id SERIAL PRIMARY KEY,
+    invoice_id INT NOT NULL,
+    kind TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ,
+
+    FOREIGN KEY (invoice_id) REFERENCES invoice(id) ON DELETE CASCADE,
+    CONSTRAINT payment_task_kind_valid CHECK (kind IN ('create_charge', 'cancel_charge'))
+);
+
+CREATE INDEX payment_task_pending ON payment_task (invoice_id, id) WHERE processed_at IS NULL;
@@ -0,0 +1,15 @@
+DELETE FROM payment_task WHERE kind = 'refund_charge';
+
+ALTER TABLE payment_task DROP CONSTRAINT payment_task_kind_valid;
+ALTER TABLE payment_task ADD CONSTRAINT payment_task_kind_valid
+    CHECK (kind IN ('create_charge', 'cancel_charge'));
+
+UPDATE invoice
+SET status = CASE WHEN expired_at IS NOT NULL THEN 'expired' ELSE 'canceled' END, paid_at = NULL
+WHERE status IN ('paid_late', 'refunded');
+
+ALTER TABLE invoice DROP CONSTRAINT invoice_status_valid;
+ALTER TABLE invoice ADD CONSTRAINT invoice_status_valid
+    CHECK (status IN ('open', 'paid', 'expired', 'canceled'));
+
+ALTER TABLE invoice DROP COLUMN refunded_at;
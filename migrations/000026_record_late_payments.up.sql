@@ -0,0 +1,11 @@
+-- A charge paid after its invoice expired or was canceled is recorded as
+-- paid_late and refunded
+ALTER TABLE invoice ADD COLUMN refunded_at TIMESTAMPTZ;
+
+ALTER TABLE invoice DROP CONSTRAINT invoice_status_valid;
+ALTER TABLE invoice ADD CONSTRAINT invoice_status_valid
+    CHECK (status IN ('open', 'paid', 'expired', 'canceled', 'paid_late', 'refunded'));
+
+ALTER TABLE payment_task DROP CONSTRAINT payment_task_kind_valid;
+ALTER TABLE payment_task ADD CONSTRAINT payment_task_kind_valid
+    CHECK (kind IN ('create_charge', 'cancel_charge', 'refund_charge'));
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/helper"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type AttendanceController struct {
+	attendanceUsecase usecase.AttendanceUsecase
+}
+
+func NewAttendanceController(attendanceRepo repository.IAttendanceRepository, courseRepo repository.ICourseRepository, transactor repository.ITransactor) *AttendanceController {
+	return &AttendanceController{
+		attendanceUsecase: usecase.NewAttendanceUsecase(attendanceRepo, courseRepo, transactor),
+	}
+}
+
+func (c *AttendanceController) SessionList(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	sessions, err := c.attendanceUsecase.GetSessions(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, sessions, nil)
+}
+
+func (c *AttendanceController) SessionCreate(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.CreateSessionInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	session, err := c.attendanceUsecase.CreateSession(req.Context(), id, input)
+	if err != nil {
+		switch {
+		case err == usecase.ErrSessionTimeRequired:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCourseNotFound, err == usecase.ErrOfferingNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusCreated, session, nil)
+}
+
+func (c *AttendanceController) AttendanceGet(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	sessionID, err := strconv.Atoi(req.PathValue("sessionID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid sessionID in url")
+		return
+	}
+
+	records, err := c.attendanceUsecase.GetSessionAttendance(req.Context(), id, sessionID)
+	if err != nil {
+		switch {
+		case err == usecase.ErrSessionNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, records, nil)
+}
+
+func (c *AttendanceController) AttendanceSubmit(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	sessionID, err := strconv.Atoi(req.PathValue("sessionID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid sessionID in url")
+		return
+	}
+
+	input := usecase.SubmitAttendanceInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	records, err := c.attendanceUsecase.SubmitAttendance(req.Context(), id, sessionID, input)
+	if err != nil {
+		var errNotEnrolled *usecase.ErrStudentsNotEnrolled
+		switch {
+		case errors.As(err, &errNotEnrolled):
+			helper.WriteJSON(res, http.StatusConflict, map[string]any{
+				"status":      http.StatusConflict,
+				"message":     err.Error(),
+				"student_ids": errNotEnrolled.StudentIDs,
+			}, nil)
+		case err == usecase.ErrNoAttendanceRecords, err == usecase.ErrInvalidAttendanceStatus, err == usecase.ErrDuplicateAttendance:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCourseNotFound, err == usecase.ErrSessionNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, records, nil)
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/helper"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type CategoryController struct {
+	categoryUsecase usecase.CategoryUsecase
+}
+
+func NewCategoryController(repo repository.ICategoryRepository, transactor repository.ITransactor) *CategoryController {
+	return &CategoryController{
+		categoryUsecase: usecase.NewCategoryUsecase(repo, transactor),
+	}
+}
+
+func (c *CategoryController) CategoryList(res http.ResponseWriter, req *http.Request) {
+	categories, err := c.categoryUsecase.GetCategories(req.Context())
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, categories, nil)
+}
+
+func (c *CategoryController) CategoryGet(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	category, err := c.categoryUsecase.GetCategory(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCategoryNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, category, nil)
+}
+
+func (c *CategoryController) CategoryCreate(res http.ResponseWriter, req *http.Request) {
+	input := usecase.CreateCategoryInput{}
+	err := helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	category, err := c.categoryUsecase.CreateCategory(req.Context(), input)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCategoryNameRequired:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCategoryNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusCreated, category, nil)
+}
+
+func (c *CategoryController) CategoryUpdate(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.UpdateCategoryInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	category, err := c.categoryUsecase.UpdateCategory(req.Context(), id, input)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCategoryNameRequired:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCategoryCycle:
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrCategoryNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, category, nil)
+}
+
+func (c *CategoryController) CategoryDelete(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	var reassignTo *int
+	if value := req.URL.Query().Get("reassign_to"); value != "" {
+		target, err := strconv.Atoi(value)
+		if err != nil {
+			helper.MessageResponse(res, req, http.StatusBadRequest, "reassign_to must be a category id")
+			return
+		}
+		reassignTo = &target
+	}
+
+	err = c.categoryUsecase.DeleteCategory(req.Context(), id, reassignTo)
+	if err != nil {
+		var errCourses *usecase.ErrCategoryHasCourses
+		switch {
+		case err == usecase.ErrInvalidReassignTarget:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case errors.As(err, &errCourses):
+			helper.WriteJSON(res, http.StatusConflict, map[string]any{
+				"status":  http.StatusConflict,
+				"message": err.Error(),
+				"courses": errCourses.Courses,
+			}, nil)
+		case err == usecase.ErrCategoryNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "category deleted")
+}
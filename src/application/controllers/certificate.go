@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/helper"
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+// A4 in landscape, in points
+const (
+	certificateWidth  = 842
+	certificateHeight = 595
+)
+
+type CertificateController struct {
+	certificateUsecase usecase.CertificateUsecase
+}
+
+func NewCertificateController(courseRepo repository.ICourseRepository, studentRepo repository.IStudentRepository, key []byte) *CertificateController {
+	return &CertificateController{
+		certificateUsecase: usecase.NewCertificateUsecase(courseRepo, studentRepo, key),
+	}
+}
+
+// CertificateDownload renders the certificate of a completed enrollment as PDF
+func (c *CertificateController) CertificateDownload(res http.ResponseWriter, req *http.Request) {
+	studentID, err := strconv.Atoi(req.PathValue("studentID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid userID in url")
+		return
+	}
+
+	courseID, err := strconv.Atoi(req.PathValue("courseID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid courseID in url")
+		return
+	}
+
+	certificate, err := c.certificateUsecase.GetCertificate(req.Context(), courseID, studentID)
+	if err != nil {
+		switch {
+		case err == usecase.ErrEnrollmentNotCompleted:
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrEnrollmentNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	var pdf bytes.Buffer
+	if err := helper.WritePDF(&pdf, certificateWidth, certificateHeight, certificateTexts(certificate)); err != nil {
+		helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/pdf")
+	res.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="certificate-%s.pdf"`, certificate.Code))
+	res.WriteHeader(http.StatusOK)
+	res.Write(pdf.Bytes())
+}
+
+// CertificateVerify is public, so anyone holding a certificate can check it
+func (c *CertificateController) CertificateVerify(res http.ResponseWriter, req *http.Request) {
+	certificate, err := c.certificateUsecase.VerifyCertificate(req.Context(), req.PathValue("code"))
+	if err != nil {
+		switch {
+		case err == usecase.ErrInvalidCertificate:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, map[string]any{
+		"valid":       true,
+		"certificate": certificate,
+	}, nil)
+}
+
+func certificateTexts(certificate *model.Certificate) []helper.PDFText {
+	return []helper.PDFText{
+		{Text: "Certificate of Completion", Size: 32, Bold: true, Y: 450},
+		{Text: "This certifies that", Size: 16, Y: 390},
+		{Text: certificate.StudentName, Size: 28, Bold: true, Y: 340},
+		{Text: "has completed the course", Size: 16, Y: 290},
+		{Text: certificate.CourseName, Size: 24, Bold: true, Y: 245},
+		{Text: "on " + certificate.CompletedAt.Format("January 2, 2006"), Size: 14, Y: 205},
+		{Text: "Verification code: " + certificate.Code, Size: 11, Y: 90},
+		{Text: "Check it at /certificates/" + certificate.Code, Size: 11, Y: 72},
+	}
+}
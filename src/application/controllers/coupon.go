@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/helper"
+)
+
+type CouponController struct {
+	couponUsecase usecase.CouponUsecase
+}
+
+func NewCouponController(deps usecase.Deps) *CouponController {
+	return &CouponController{
+		couponUsecase: usecase.NewCouponUsecase(deps),
+	}
+}
+
+// couponErrorResponse reports the errors shared by creating and updating a
+// coupon
+func couponErrorResponse(res http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case err == usecase.ErrCouponCodeRequired, err == usecase.ErrInvalidDiscountType, err == usecase.ErrInvalidPercentDiscount,
+		err == usecase.ErrInvalidFixedDiscount, err == usecase.ErrInvalidMaxRedemptions, err == usecase.ErrInvalidValidity:
+		helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+	case err == usecase.ErrCouponCodeTaken, err == usecase.ErrCouponCapBelowRedemptions:
+		helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+	case err == usecase.ErrCouponNotFound, err == usecase.ErrCourseNotFound:
+		helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+	default:
+		helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+func (c *CouponController) CouponList(res http.ResponseWriter, req *http.Request) {
+	coupons, err := c.couponUsecase.GetCoupons(req.Context())
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, coupons, nil)
+}
+
+func (c *CouponController) CouponGet(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	coupon, err := c.couponUsecase.GetCoupon(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCouponNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, coupon, nil)
+}
+
+func (c *CouponController) CouponCreate(res http.ResponseWriter, req *http.Request) {
+	input := usecase.CreateCouponInput{}
+	err := helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	coupon, err := c.couponUsecase.CreateCoupon(req.Context(), input)
+	if err != nil {
+		couponErrorResponse(res, req, err)
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusCreated, coupon, nil)
+}
+
+func (c *CouponController) CouponUpdate(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.UpdateCouponInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	coupon, err := c.couponUsecase.UpdateCoupon(req.Context(), id, input)
+	if err != nil {
+		couponErrorResponse(res, req, err)
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, coupon, nil)
+}
+
+func (c *CouponController) CouponDelete(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	err = c.couponUsecase.DeleteCoupon(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCouponNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "coupon deleted")
+}
@@ -1,13 +1,16 @@
 package controllers
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/felipedavid/vrcursos/src/core/domain"
 	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
 	"github.com/felipedavid/vrcursos/src/core/helper"
+	"github.com/felipedavid/vrcursos/src/core/model"
 	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
 )
 
@@ -15,9 +18,9 @@ type CourseController struct {
 	courseUsecase usecase.CourseUsecase
 }
 
-func NewCourseController(courseRepo repository.ICourseRepository, studentRepo repository.IStudentRepository) *CourseController {
+func NewCourseController(deps usecase.Deps, config usecase.Config) *CourseController {
 	return &CourseController{
-		courseUsecase: usecase.NewCourseUsecase(courseRepo, studentRepo),
+		courseUsecase: usecase.NewCourseUsecase(deps, config),
 	}
 }
 
@@ -28,9 +31,20 @@ func (c *CourseController) CourseGet(res http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	course, err := c.courseUsecase.GetCourse(context.Background(), id)
+	includeDeleted, err := includeDeletedParam(req)
 	if err != nil {
-		helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		return
+	}
+
+	course, err := c.courseUsecase.GetCourse(req.Context(), id, includeDeleted)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		}
 		return
 	}
 
@@ -45,9 +59,17 @@ func (c *CourseController) CourseCreate(res http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	_, err = c.courseUsecase.CreateCourse(context.Background(), input)
+	_, err = c.courseUsecase.CreateCourse(req.Context(), input)
 	if err != nil {
-		helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		switch {
+		case err == usecase.ErrInvalidCapacity, err == usecase.ErrInvalidEnrollmentWindow, err == usecase.ErrInvalidTag,
+			err == usecase.ErrInvalidPrice, err == usecase.ErrInvalidCurrency:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCategoryNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
 		return
 	}
 
@@ -55,9 +77,35 @@ func (c *CourseController) CourseCreate(res http.ResponseWriter, req *http.Reque
 }
 
 func (c *CourseController) CourseList(res http.ResponseWriter, req *http.Request) {
-	courses, err := c.courseUsecase.GetCourses(context.Background())
+	includeDeleted, err := includeDeletedParam(req)
 	if err != nil {
-		helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		return
+	}
+
+	filter := repository.CourseFilter{
+		Tags:           req.URL.Query()["tag"],
+		Statuses:       req.URL.Query()["status"],
+		IncludeDeleted: includeDeleted,
+	}
+
+	if value := req.URL.Query().Get("category"); value != "" {
+		categoryID, err := strconv.Atoi(value)
+		if err != nil {
+			helper.MessageResponse(res, req, http.StatusBadRequest, "category must be a category id")
+			return
+		}
+		filter.CategoryID = &categoryID
+	}
+
+	courses, err := c.courseUsecase.GetCourses(req.Context(), filter)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInvalidTag, err == usecase.ErrInvalidCourseStatus:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		default:
+			helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		}
 		return
 	}
 
@@ -78,9 +126,16 @@ func (c *CourseController) CourseUpdate(res http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	_, err = c.courseUsecase.UpdateCourse(context.Background(), id, input)
+	_, err = c.courseUsecase.UpdateCourse(req.Context(), id, input)
 	if err != nil {
-		helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		switch {
+		case err == usecase.ErrInvalidTag, err == usecase.ErrInvalidPrice, err == usecase.ErrInvalidCurrency:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCourseNotFound, err == usecase.ErrCategoryNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		}
 		return
 	}
 
@@ -94,15 +149,148 @@ func (c *CourseController) CourseDelete(res http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	err = c.courseUsecase.DeleteCourse(context.Background(), id)
+	cascade := req.URL.Query().Get("cascade") == "true"
+
+	err = c.courseUsecase.DeleteCourse(req.Context(), id, cascade)
 	if err != nil {
-		helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		var errEnrollments *usecase.ErrCourseHasEnrollments
+		switch {
+		case errors.As(err, &errEnrollments):
+			helper.WriteJSON(res, http.StatusConflict, map[string]any{
+				"status":      http.StatusConflict,
+				"message":     err.Error(),
+				"enrollments": errEnrollments.Enrollments,
+			}, nil)
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		}
 		return
 	}
 
 	helper.WriteJSON(res, http.StatusOK, map[string]any{"message": "course deleted"}, nil)
 }
 
+func (c *CourseController) CourseRestore(res http.ResponseWriter, req *http.Request) {
+	if !domain.IsAdmin(req.Context()) {
+		helper.MessageResponse(res, req, http.StatusForbidden, domain.ErrAdminRequired.Error())
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	course, err := c.courseUsecase.RestoreCourse(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCourseNotDeleted, err == usecase.ErrPrerequisiteCycle:
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, course, nil)
+}
+
+func (c *CourseController) CoursePublish(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	course, err := c.courseUsecase.PublishCourse(req.Context(), id)
+	courseStatusResponse(res, req, course, err)
+}
+
+func (c *CourseController) CourseArchive(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	course, err := c.courseUsecase.ArchiveCourse(req.Context(), id)
+	courseStatusResponse(res, req, course, err)
+}
+
+// courseStatusResponse reports the outcome of publishing or archiving a course
+func courseStatusResponse(res http.ResponseWriter, req *http.Request, course *model.Course, err error) {
+	if err != nil {
+		switch {
+		case err == usecase.ErrCourseNameRequired, err == usecase.ErrCourseDescriptionRequired:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCourseAlreadyPublished, err == usecase.ErrCourseNotArchivable:
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, course, nil)
+}
+
+// enrollmentOptions reads the administrative overrides and the coupon code
+// from the query string. Only authenticated administrators can override the
+// enrollment window.
+func enrollmentOptions(req *http.Request) (usecase.EnrollmentOptions, error) {
+	opts := usecase.EnrollmentOptions{
+		IgnoreEnrollmentWindow: req.URL.Query().Get("override_window") == "true",
+		CouponCode:             req.URL.Query().Get("coupon"),
+	}
+
+	if opts.IgnoreEnrollmentWindow && !domain.IsAdmin(req.Context()) {
+		return opts, domain.ErrWindowOverrideDenied
+	}
+
+	return opts, nil
+}
+
+// includeDeletedParam reads the include_deleted flag from the query string.
+// Deleted rows keep personal data, so only authenticated administrators can
+// ask for them.
+func includeDeletedParam(req *http.Request) (bool, error) {
+	includeDeleted := req.URL.Query().Get("include_deleted") == "true"
+
+	if includeDeleted && !domain.IsAdmin(req.Context()) {
+		return false, domain.ErrAdminRequired
+	}
+
+	return includeDeleted, nil
+}
+
+// missingPrerequisitesResponse lists the prerequisites the student still has to
+// complete along with the error message
+func missingPrerequisitesResponse(res http.ResponseWriter, req *http.Request, err *usecase.ErrMissingPrerequisites) {
+	helper.WriteJSON(res, http.StatusForbidden, map[string]any{
+		"status":                http.StatusForbidden,
+		"message":               err.Error(),
+		"missing_prerequisites": err.Missing,
+	}, nil)
+}
+
+// scheduleConflictResponse names the course and slot clashing with the course
+// the student tried to enroll in
+func scheduleConflictResponse(res http.ResponseWriter, req *http.Request, err *usecase.ErrScheduleConflict) {
+	helper.WriteJSON(res, http.StatusConflict, map[string]any{
+		"status":             http.StatusConflict,
+		"message":            err.Error(),
+		"conflicting_course": err.Course,
+		"conflicting_slot":   err.Slot,
+	}, nil)
+}
+
 func (c *CourseController) EnrollStudent(res http.ResponseWriter, req *http.Request) {
 	studentID, err := strconv.Atoi(req.PathValue("studentID"))
 	if err != nil {
@@ -116,21 +304,65 @@ func (c *CourseController) EnrollStudent(res http.ResponseWriter, req *http.Requ
 		return
 	}
 
-	err = c.courseUsecase.EnrollStudent(context.Background(), courseID, studentID)
+	opts, err := enrollmentOptions(req)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		return
+	}
+
+	output, err := c.courseUsecase.EnrollStudent(req.Context(), courseID, studentID, opts)
+	enrollResponse(res, req, output, err)
+}
+
+// enrollResponse reports the outcome of enrolling a student, in the course
+// itself or in one of its offerings
+func enrollResponse(res http.ResponseWriter, req *http.Request, output *usecase.EnrollStudentOutput, err error) {
 	if err != nil {
+		var errMissing *usecase.ErrMissingPrerequisites
+		var errConflict *usecase.ErrScheduleConflict
 		switch {
-		case err == usecase.ErrCourseFull:
-			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
-		case err == usecase.ErrEnrolledTooManyCourses:
+		case errors.As(err, &errMissing):
+			missingPrerequisitesResponse(res, req, errMissing)
+		case errors.As(err, &errConflict):
+			scheduleConflictResponse(res, req, errConflict)
+		case err == usecase.ErrEnrollmentWindowClosed, err == usecase.ErrCourseNotPublished, err == usecase.ErrCourseArchived:
+			helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		case errors.Is(err, usecase.ErrEnrolledTooManyCourses):
 			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
 		case err == repository.ErrStudentAlreadyEnrolled:
 			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrStudentAlreadyInWaitlist:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCouponNotValid, err == usecase.ErrCouponNotApplicable:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCouponExhausted:
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrCourseNotFound, err == usecase.ErrOfferingNotFound, err == usecase.ErrStudentNotFound, err == usecase.ErrCouponNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
 		default:
 			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
 		}
 		return
 	}
 
+	if output.Result == usecase.EnrollmentResultWaitlisted {
+		helper.WriteJSON(res, http.StatusAccepted, map[string]any{
+			"status":            http.StatusAccepted,
+			"message":           "course is full, student added to the waitlist",
+			"waitlist_position": output.WaitlistPosition,
+		}, nil)
+		return
+	}
+
+	if output.Invoice != nil {
+		helper.WriteJSON(res, http.StatusAccepted, map[string]any{
+			"status":  http.StatusAccepted,
+			"message": "student enrolled, the enrollment stays pending until the invoice is paid",
+			"invoice": output.Invoice,
+		}, nil)
+		return
+	}
+
 	helper.MessageResponse(res, req, http.StatusOK, "student enrolled in the course successfully")
 }
 
@@ -147,11 +379,390 @@ func (c *CourseController) UnenrollStudent(res http.ResponseWriter, req *http.Re
 		return
 	}
 
-	err = c.courseUsecase.UnenrollStudent(context.Background(), courseID, studentID)
+	opts, err := enrollmentOptions(req)
 	if err != nil {
-		helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		return
+	}
+
+	err = c.courseUsecase.UnenrollStudent(req.Context(), courseID, studentID, opts)
+	if err != nil {
+		switch {
+		case err == usecase.ErrEnrollmentWindowClosed:
+			helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		case err == usecase.ErrCourseNotFound, err == usecase.ErrEnrollmentNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
 		return
 	}
 
 	helper.MessageResponse(res, req, http.StatusOK, "student unenrolled from the course successfully")
 }
+
+func (c *CourseController) WaitlistGet(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	waitlist, err := c.courseUsecase.GetWaitlist(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, waitlist, nil)
+}
+
+func (c *CourseController) WaitlistJoin(res http.ResponseWriter, req *http.Request) {
+	studentID, err := strconv.Atoi(req.PathValue("studentID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid userID in url")
+		return
+	}
+
+	courseID, err := strconv.Atoi(req.PathValue("courseID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid courseID in url")
+		return
+	}
+
+	position, err := c.courseUsecase.JoinWaitlist(req.Context(), courseID, studentID)
+	if err != nil {
+		var errMissing *usecase.ErrMissingPrerequisites
+		var errConflict *usecase.ErrScheduleConflict
+		switch {
+		case errors.As(err, &errMissing):
+			missingPrerequisitesResponse(res, req, errMissing)
+		case errors.As(err, &errConflict):
+			scheduleConflictResponse(res, req, errConflict)
+		case err == usecase.ErrEnrollmentWindowClosed, err == usecase.ErrCourseNotPublished, err == usecase.ErrCourseArchived:
+			helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		case err == usecase.ErrCourseNotFull:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrStudentAlreadyEnrolled:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrStudentAlreadyInWaitlist:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCourseNotFound, err == usecase.ErrStudentNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusCreated, map[string]any{
+		"status":            http.StatusCreated,
+		"message":           "student added to the waitlist",
+		"waitlist_position": position,
+	}, nil)
+}
+
+func (c *CourseController) WaitlistLeave(res http.ResponseWriter, req *http.Request) {
+	studentID, err := strconv.Atoi(req.PathValue("studentID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid userID in url")
+		return
+	}
+
+	courseID, err := strconv.Atoi(req.PathValue("courseID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid courseID in url")
+		return
+	}
+
+	err = c.courseUsecase.LeaveWaitlist(req.Context(), courseID, studentID)
+	if err != nil {
+		switch {
+		case err == usecase.ErrStudentNotInWaitlist:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "student removed from the waitlist")
+}
+
+func (c *CourseController) EnrollmentStatusUpdate(res http.ResponseWriter, req *http.Request) {
+	studentID, err := strconv.Atoi(req.PathValue("studentID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid userID in url")
+		return
+	}
+
+	courseID, err := strconv.Atoi(req.PathValue("courseID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid courseID in url")
+		return
+	}
+
+	input := usecase.ChangeEnrollmentStatusInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	enrollment, err := c.courseUsecase.ChangeEnrollmentStatus(req.Context(), courseID, studentID, input)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInvalidEnrollmentStatus, err == usecase.ErrReasonRequired:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrInvalidStatusTransition:
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrCourseNotFound, err == usecase.ErrEnrollmentNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, enrollment, nil)
+}
+
+func (c *CourseController) PrerequisiteList(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	prerequisites, err := c.courseUsecase.GetPrerequisites(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, prerequisites, nil)
+}
+
+func (c *CourseController) PrerequisiteAdd(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	prerequisiteID, err := strconv.Atoi(req.PathValue("prerequisiteID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid prerequisiteID in url")
+		return
+	}
+
+	err = c.courseUsecase.AddPrerequisite(req.Context(), id, prerequisiteID)
+	if err != nil {
+		switch {
+		case err == usecase.ErrPrerequisiteCycle, err == usecase.ErrPrerequisiteAlreadyAdded:
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusCreated, "prerequisite added")
+}
+
+func (c *CourseController) PrerequisiteRemove(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	prerequisiteID, err := strconv.Atoi(req.PathValue("prerequisiteID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid prerequisiteID in url")
+		return
+	}
+
+	err = c.courseUsecase.RemovePrerequisite(req.Context(), id, prerequisiteID)
+	if err != nil {
+		switch {
+		case err == usecase.ErrPrerequisiteNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "prerequisite removed")
+}
+
+func (c *CourseController) SlotList(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	slots, err := c.courseUsecase.GetSlots(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, slots, nil)
+}
+
+func (c *CourseController) SlotAdd(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.AddSlotInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	slot, err := c.courseUsecase.AddSlot(req.Context(), id, input)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInvalidWeekday, err == usecase.ErrInvalidSlotTime:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrSlotOverlaps:
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusCreated, slot, nil)
+}
+
+func (c *CourseController) SlotRemove(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	slotID, err := strconv.Atoi(req.PathValue("slotID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid slotID in url")
+		return
+	}
+
+	err = c.courseUsecase.RemoveSlot(req.Context(), id, slotID)
+	if err != nil {
+		switch {
+		case err == usecase.ErrSlotNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "slot removed")
+}
+
+func (c *CourseController) BulkEnroll(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.BulkEnrollInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	input.Atomic = req.URL.Query().Get("atomic") == "true"
+
+	opts, err := enrollmentOptions(req)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		return
+	}
+
+	output, err := c.courseUsecase.BulkEnroll(req.Context(), id, input, opts)
+	if err != nil {
+		switch {
+		case err == usecase.ErrNoStudents:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrEnrollmentWindowClosed, err == usecase.ErrCourseNotPublished, err == usecase.ErrCourseArchived:
+			helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if !output.Committed {
+		status = http.StatusConflict
+	}
+
+	helper.WriteJSON(res, status, output, nil)
+}
+
+func (c *CourseController) CourseStudents(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	search := req.URL.Query().Get("search")
+
+	var asOf *time.Time
+	if value := req.URL.Query().Get("as_of"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			helper.MessageResponse(res, req, http.StatusBadRequest, "as_of must be a RFC 3339 timestamp, like '2024-03-03T12:00:00Z'")
+			return
+		}
+		asOf = &t
+	}
+
+	students, err := c.courseUsecase.GetCourseStudents(req.Context(), id, search, asOf)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, students, nil)
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/felipedavid/vrcursos/src/core/domain"
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/helper"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type GradeController struct {
+	gradeUsecase usecase.GradeUsecase
+}
+
+func NewGradeController(gradeRepo repository.IGradeRepository, courseRepo repository.ICourseRepository, studentRepo repository.IStudentRepository, transactor repository.ITransactor) *GradeController {
+	return &GradeController{
+		gradeUsecase: usecase.NewGradeUsecase(gradeRepo, courseRepo, studentRepo, transactor),
+	}
+}
+
+func (c *GradeController) GradeSet(res http.ResponseWriter, req *http.Request) {
+	studentID, err := strconv.Atoi(req.PathValue("studentID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid userID in url")
+		return
+	}
+
+	courseID, err := strconv.Atoi(req.PathValue("courseID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid courseID in url")
+		return
+	}
+
+	input := usecase.SetGradeInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	grade, err := c.gradeUsecase.SetGrade(req.Context(), courseID, studentID, input)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInvalidGrade, err == usecase.ErrCorrectionReasonRequired:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrEnrollmentNotGradable:
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrStudentNotFound, err == usecase.ErrEnrollmentNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, grade, nil)
+}
+
+func (c *GradeController) GradeGet(res http.ResponseWriter, req *http.Request) {
+	studentID, err := strconv.Atoi(req.PathValue("studentID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid userID in url")
+		return
+	}
+
+	courseID, err := strconv.Atoi(req.PathValue("courseID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid courseID in url")
+		return
+	}
+
+	grade, err := c.gradeUsecase.GetGrade(req.Context(), courseID, studentID)
+	if err != nil {
+		switch {
+		case err == usecase.ErrEnrollmentNotFound, err == usecase.ErrGradeNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, grade, nil)
+}
+
+func (c *GradeController) Transcript(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	transcript, err := c.gradeUsecase.GetTranscript(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == domain.ErrStudentNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, transcript, nil)
+}
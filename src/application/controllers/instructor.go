@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/helper"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type InstructorController struct {
+	instructorUsecase usecase.InstructorUsecase
+}
+
+func NewInstructorController(repo repository.IInstructorRepository, courseRepo repository.ICourseRepository) *InstructorController {
+	return &InstructorController{
+		instructorUsecase: usecase.NewInstructorUsecase(repo, courseRepo),
+	}
+}
+
+func (c *InstructorController) InstructorGet(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	instructor, err := c.instructorUsecase.GetInstructor(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInstructorNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, instructor, nil)
+}
+
+func (c *InstructorController) InstructorCreate(res http.ResponseWriter, req *http.Request) {
+	input := usecase.CreateInstructorInput{}
+	err := helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	instructor, err := c.instructorUsecase.CreateInstructor(req.Context(), input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusCreated, instructor, nil)
+}
+
+func (c *InstructorController) InstructorList(res http.ResponseWriter, req *http.Request) {
+	search := req.URL.Query().Get("search")
+
+	instructors, err := c.instructorUsecase.GetInstructors(req.Context(), search)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, instructors, nil)
+}
+
+func (c *InstructorController) InstructorUpdate(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.UpdateInstructorInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	instructor, err := c.instructorUsecase.UpdateInstructor(req.Context(), id, input)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInstructorNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, instructor, nil)
+}
+
+func (c *InstructorController) InstructorDelete(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	err = c.instructorUsecase.DeleteInstructor(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInstructorNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "unable to delete instructor")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "instructor deleted")
+}
+
+func (c *InstructorController) InstructorCourses(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	courses, err := c.instructorUsecase.GetInstructorCourses(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInstructorNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, courses, nil)
+}
+
+func (c *InstructorController) InstructorAssign(res http.ResponseWriter, req *http.Request) {
+	courseID, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	instructorID, err := strconv.Atoi(req.PathValue("instructorID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid instructorID in url")
+		return
+	}
+
+	input := usecase.AssignInstructorInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err = c.instructorUsecase.AssignInstructor(req.Context(), courseID, instructorID, input)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInvalidInstructorRole:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCourseNotFound, err == usecase.ErrInstructorNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "instructor assigned to the course")
+}
+
+func (c *InstructorController) InstructorUnassign(res http.ResponseWriter, req *http.Request) {
+	courseID, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	instructorID, err := strconv.Atoi(req.PathValue("instructorID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid instructorID in url")
+		return
+	}
+
+	err = c.instructorUsecase.UnassignInstructor(req.Context(), courseID, instructorID)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInstructorNotAssigned:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "instructor removed from the course")
+}
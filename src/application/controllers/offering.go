@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/helper"
+)
+
+func (c *CourseController) OfferingList(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	offerings, err := c.courseUsecase.GetOfferings(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, offerings, nil)
+}
+
+func (c *CourseController) OfferingCreate(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.CreateOfferingInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	offering, err := c.courseUsecase.CreateOffering(req.Context(), id, input)
+	if err != nil {
+		var errRoom *usecase.ErrRoomTooSmall
+		switch {
+		case err == usecase.ErrInvalidCapacity, err == usecase.ErrInvalidEnrollmentWindow,
+			err == usecase.ErrOfferingNameRequired, err == usecase.ErrInvalidOfferingDates:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case errors.As(err, &errRoom):
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusCreated, offering, nil)
+}
+
+func (c *CourseController) OfferingGet(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	offering, err := c.courseUsecase.GetOffering(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrOfferingNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, offering, nil)
+}
+
+func (c *CourseController) OfferingUpdate(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.UpdateOfferingInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	offering, err := c.courseUsecase.UpdateOffering(req.Context(), id, input)
+	if err != nil {
+		var errCapacity *usecase.ErrCapacityBelowEnrollment
+		var errRoom *usecase.ErrRoomTooSmall
+		switch {
+		case err == usecase.ErrInvalidCapacity, err == usecase.ErrInvalidEnrollmentWindow,
+			err == usecase.ErrOfferingNameRequired, err == usecase.ErrInvalidOfferingDates:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case errors.As(err, &errCapacity), errors.As(err, &errRoom):
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrOfferingNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, offering, nil)
+}
+
+func (c *CourseController) OfferingStudents(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	students, err := c.courseUsecase.GetOfferingStudents(req.Context(), id, req.URL.Query().Get("search"))
+	if err != nil {
+		switch {
+		case err == usecase.ErrOfferingNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, students, nil)
+}
+
+func (c *CourseController) EnrollStudentInOffering(res http.ResponseWriter, req *http.Request) {
+	studentID, err := strconv.Atoi(req.PathValue("studentID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid userID in url")
+		return
+	}
+
+	offeringID, err := strconv.Atoi(req.PathValue("offeringID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid offeringID in url")
+		return
+	}
+
+	opts, err := enrollmentOptions(req)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		return
+	}
+
+	output, err := c.courseUsecase.EnrollStudentInOffering(req.Context(), offeringID, studentID, opts)
+	enrollResponse(res, req, output, err)
+}
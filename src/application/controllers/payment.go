@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/helper"
+	"github.com/felipedavid/vrcursos/src/infrastructure/payment"
+)
+
+type PaymentController struct {
+	paymentUsecase usecase.PaymentUsecase
+	gateway        payment.IPaymentGateway
+}
+
+func NewPaymentController(deps usecase.Deps, config usecase.Config) *PaymentController {
+	return &PaymentController{
+		paymentUsecase: usecase.NewPaymentUsecase(deps, config),
+		gateway:        deps.Gateway,
+	}
+}
+
+// PaymentCallback receives the notifications of the payment gateway
+func (c *PaymentController) PaymentCallback(res http.ResponseWriter, req *http.Request) {
+	notification, err := c.gateway.ParseNotification(req)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInvalidNotification:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	invoice, err := c.paymentUsecase.ConfirmPayment(req.Context(), notification)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInvoiceNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, invoice, nil)
+}
+
+func (c *PaymentController) InvoiceGet(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	invoice, err := c.paymentUsecase.GetInvoice(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInvoiceNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, invoice, nil)
+}
+
+func (c *PaymentController) StudentInvoices(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	invoices, err := c.paymentUsecase.GetStudentInvoices(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrStudentNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, invoices, nil)
+}
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/helper"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type RoomController struct {
+	roomUsecase usecase.RoomUsecase
+}
+
+func NewRoomController(repo repository.IRoomRepository, courseRepo repository.ICourseRepository, transactor repository.ITransactor) *RoomController {
+	return &RoomController{
+		roomUsecase: usecase.NewRoomUsecase(repo, courseRepo, transactor),
+	}
+}
+
+func (c *RoomController) RoomList(res http.ResponseWriter, req *http.Request) {
+	rooms, err := c.roomUsecase.GetRooms(req.Context())
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, rooms, nil)
+}
+
+func (c *RoomController) RoomGet(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	room, err := c.roomUsecase.GetRoom(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrRoomNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, room, nil)
+}
+
+func (c *RoomController) RoomCreate(res http.ResponseWriter, req *http.Request) {
+	input := usecase.CreateRoomInput{}
+	err := helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	room, err := c.roomUsecase.CreateRoom(req.Context(), input)
+	if err != nil {
+		switch {
+		case err == usecase.ErrRoomNameRequired, err == usecase.ErrInvalidSeats:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusCreated, room, nil)
+}
+
+func (c *RoomController) RoomUpdate(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.UpdateRoomInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	room, err := c.roomUsecase.UpdateRoom(req.Context(), id, input)
+	if err != nil {
+		var errRoom *usecase.ErrRoomTooSmall
+		switch {
+		case err == usecase.ErrRoomNameRequired, err == usecase.ErrInvalidSeats:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case errors.As(err, &errRoom):
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrRoomNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, room, nil)
+}
+
+func (c *RoomController) RoomDelete(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	err = c.roomUsecase.DeleteRoom(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrRoomNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "room deleted")
+}
+
+func (c *RoomController) RoomTimetable(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	timetable, err := c.roomUsecase.GetTimetable(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrRoomNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, timetable, nil)
+}
+
+func (c *RoomController) RoomBook(res http.ResponseWriter, req *http.Request) {
+	courseID, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	slotID, err := strconv.Atoi(req.PathValue("slotID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid slotID in url")
+		return
+	}
+
+	input := usecase.BookRoomInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	slot, err := c.roomUsecase.BookRoom(req.Context(), courseID, slotID, input)
+	if err != nil {
+		var errRoom *usecase.ErrRoomTooSmall
+		var errBooked *usecase.ErrRoomBooked
+		switch {
+		case errors.As(err, &errRoom):
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case errors.As(err, &errBooked):
+			helper.WriteJSON(res, http.StatusConflict, map[string]any{
+				"status":              http.StatusConflict,
+				"message":             err.Error(),
+				"conflicting_booking": errBooked.Booking,
+			}, nil)
+		case err == usecase.ErrCourseNotFound, err == usecase.ErrSlotNotFound, err == usecase.ErrRoomNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, slot, nil)
+}
+
+func (c *RoomController) RoomRelease(res http.ResponseWriter, req *http.Request) {
+	courseID, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	slotID, err := strconv.Atoi(req.PathValue("slotID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid slotID in url")
+		return
+	}
+
+	err = c.roomUsecase.ReleaseRoom(req.Context(), courseID, slotID)
+	if err != nil {
+		switch {
+		case err == usecase.ErrSlotNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "room released")
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/helper"
+)
+
+type ScholarshipController struct {
+	scholarshipUsecase usecase.ScholarshipUsecase
+}
+
+func NewScholarshipController(deps usecase.Deps) *ScholarshipController {
+	return &ScholarshipController{
+		scholarshipUsecase: usecase.NewScholarshipUsecase(deps),
+	}
+}
+
+func (c *ScholarshipController) ScholarshipList(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	scholarships, err := c.scholarshipUsecase.GetStudentScholarships(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrStudentNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, scholarships, nil)
+}
+
+func (c *ScholarshipController) ScholarshipCreate(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.CreateScholarshipInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	scholarship, err := c.scholarshipUsecase.CreateScholarship(req.Context(), id, input)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInvalidScholarshipPercent, err == usecase.ErrScholarshipReasonRequired, err == usecase.ErrInvalidValidity:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrStudentNotFound, err == usecase.ErrCourseNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusCreated, scholarship, nil)
+}
+
+func (c *ScholarshipController) ScholarshipDelete(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	scholarshipID, err := strconv.Atoi(req.PathValue("scholarshipID"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid scholarshipID in url")
+		return
+	}
+
+	err = c.scholarshipUsecase.DeleteScholarship(req.Context(), id, scholarshipID)
+	if err != nil {
+		switch {
+		case err == usecase.ErrScholarshipNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "scholarship deleted")
+}
@@ -1,7 +1,7 @@
 package controllers
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -9,16 +9,15 @@ import (
 	"github.com/felipedavid/vrcursos/src/core/domain"
 	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
 	"github.com/felipedavid/vrcursos/src/core/helper"
-	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
 )
 
 type StudentController struct {
 	studentUsecase usecase.StudentUsecase
 }
 
-func NewStudentController(repo repository.IStudentRepository) *StudentController {
+func NewStudentController(deps usecase.Deps, config usecase.Config) *StudentController {
 	return &StudentController{
-		studentUsecase: usecase.NewStudentUsecase(repo),
+		studentUsecase: usecase.NewStudentUsecase(deps, config),
 	}
 }
 
@@ -29,15 +28,54 @@ func (c *StudentController) StudentGet(res http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	student, err := c.studentUsecase.GetStudent(context.Background(), id)
+	includeCourses := req.URL.Query().Get("include") == "courses"
+	includeDeleted, err := includeDeletedParam(req)
 	if err != nil {
-		helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		return
+	}
+
+	student, err := c.studentUsecase.GetStudent(req.Context(), id, includeCourses, includeDeleted)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrStudentNotFound):
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		}
 		return
 	}
 
 	helper.WriteJSON(res, http.StatusOK, student, nil)
 }
 
+// studentProfileErrorResponse reports the errors shared by creating and
+// updating a student
+func studentProfileErrorResponse(res http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case err == usecase.ErrInvalidEmail, err == usecase.ErrInvalidPhone, err == usecase.ErrInvalidBirthDate, err == usecase.ErrInvalidCPF:
+		helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+	case err == usecase.ErrStudentEmailTaken:
+		studentConflictResponse(res, err, "email")
+	case err == usecase.ErrStudentCPFTaken:
+		studentConflictResponse(res, err, "cpf")
+	case errors.Is(err, domain.ErrStudentNotFound):
+		helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+	default:
+		helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+	}
+}
+
+// studentConflictResponse reports which field of the student is already in
+// use by another student
+func studentConflictResponse(res http.ResponseWriter, err error, field string) {
+	helper.WriteJSON(res, http.StatusConflict, map[string]any{
+		"status":  http.StatusConflict,
+		"message": err.Error(),
+		"field":   field,
+	}, nil)
+}
+
 func (c *StudentController) StudentCreate(res http.ResponseWriter, req *http.Request) {
 	input := usecase.CreateStudentInput{}
 	err := helper.ReadJSON(res, req, &input)
@@ -46,9 +84,9 @@ func (c *StudentController) StudentCreate(res http.ResponseWriter, req *http.Req
 		return
 	}
 
-	_, err = c.studentUsecase.CreateStudent(context.Background(), input)
+	_, err = c.studentUsecase.CreateStudent(req.Context(), input)
 	if err != nil {
-		helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		studentProfileErrorResponse(res, req, err)
 		return
 	}
 
@@ -57,11 +95,16 @@ func (c *StudentController) StudentCreate(res http.ResponseWriter, req *http.Req
 
 func (c *StudentController) StudentList(res http.ResponseWriter, req *http.Request) {
 	search := req.URL.Query().Get("search")
+	includeDeleted, err := includeDeletedParam(req)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		return
+	}
 
-	students, err := c.studentUsecase.GetStudents(context.Background(), search)
+	students, err := c.studentUsecase.GetStudents(req.Context(), search, includeDeleted)
 	if err != nil {
 		switch {
-		case err == domain.ErrStudentNotFound:
+		case errors.Is(err, domain.ErrStudentNotFound):
 			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
 		default:
 			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
@@ -86,9 +129,9 @@ func (c *StudentController) StudentUpdate(res http.ResponseWriter, req *http.Req
 		return
 	}
 
-	student, err := c.studentUsecase.UpdateStudent(context.Background(), id, input)
+	student, err := c.studentUsecase.UpdateStudent(req.Context(), id, input)
 	if err != nil {
-		helper.WriteJSON(res, http.StatusInternalServerError, nil, nil)
+		studentProfileErrorResponse(res, req, err)
 		return
 	}
 
@@ -102,11 +145,190 @@ func (c *StudentController) StudentDelete(res http.ResponseWriter, req *http.Req
 		return
 	}
 
-	err = c.studentUsecase.DeleteStudent(context.Background(), id)
+	cascade := req.URL.Query().Get("cascade") == "true"
+
+	err = c.studentUsecase.DeleteStudent(req.Context(), id, cascade)
 	if err != nil {
-		helper.MessageResponse(res, req, http.StatusInternalServerError, "unable to delete student")
+		var errEnrollments *usecase.ErrStudentHasEnrollments
+		switch {
+		case errors.As(err, &errEnrollments):
+			helper.WriteJSON(res, http.StatusConflict, map[string]any{
+				"status":      http.StatusConflict,
+				"message":     err.Error(),
+				"enrollments": errEnrollments.Enrollments,
+			}, nil)
+		case errors.Is(err, domain.ErrStudentNotFound):
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "unable to delete student")
+		}
 		return
 	}
 
 	helper.MessageResponse(res, req, http.StatusOK, "student deleted")
 }
+
+func (c *StudentController) StudentRestore(res http.ResponseWriter, req *http.Request) {
+	if !domain.IsAdmin(req.Context()) {
+		helper.MessageResponse(res, req, http.StatusForbidden, domain.ErrAdminRequired.Error())
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	student, err := c.studentUsecase.RestoreStudent(req.Context(), id)
+	if err != nil {
+		switch {
+		case err == usecase.ErrStudentNotDeleted:
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case errors.Is(err, domain.ErrStudentNotFound):
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, student, nil)
+}
+
+func (c *StudentController) EnrollmentLimitSet(res http.ResponseWriter, req *http.Request) {
+	if !domain.IsAdmin(req.Context()) {
+		helper.MessageResponse(res, req, http.StatusForbidden, domain.ErrAdminRequired.Error())
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.SetEnrollmentLimitInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err = c.studentUsecase.SetEnrollmentLimit(req.Context(), id, input)
+	if err != nil {
+		switch {
+		case err == usecase.ErrInvalidEnrollmentLimit:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case errors.Is(err, domain.ErrStudentNotFound):
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "enrollment limit updated")
+}
+
+func (c *StudentController) EnrollmentLimitClear(res http.ResponseWriter, req *http.Request) {
+	if !domain.IsAdmin(req.Context()) {
+		helper.MessageResponse(res, req, http.StatusForbidden, domain.ErrAdminRequired.Error())
+		return
+	}
+
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	err = c.studentUsecase.ClearEnrollmentLimit(req.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrStudentNotFound):
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.MessageResponse(res, req, http.StatusOK, "enrollment limit cleared")
+}
+
+func (c *StudentController) StudentCourses(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	search := req.URL.Query().Get("search")
+
+	courses, err := c.studentUsecase.GetStudentCourses(req.Context(), id, search)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrStudentNotFound):
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, courses, nil)
+}
+
+func (c *StudentController) StudentTransfer(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid id in url")
+		return
+	}
+
+	input := usecase.TransferStudentInput{}
+	err = helper.ReadJSON(res, req, &input)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	opts, err := enrollmentOptions(req)
+	if err != nil {
+		helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		return
+	}
+
+	enrollment, err := c.studentUsecase.TransferStudent(req.Context(), id, input, opts)
+	if err != nil {
+		var errMissing *usecase.ErrMissingPrerequisites
+		var errConflict *usecase.ErrScheduleConflict
+		switch {
+		case errors.As(err, &errMissing):
+			missingPrerequisitesResponse(res, req, errMissing)
+		case errors.As(err, &errConflict):
+			scheduleConflictResponse(res, req, errConflict)
+		case err == usecase.ErrEnrollmentWindowClosed, err == usecase.ErrCourseNotPublished, err == usecase.ErrCourseArchived:
+			helper.MessageResponse(res, req, http.StatusForbidden, err.Error())
+		case err == usecase.ErrTransferSameCourse:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case errors.Is(err, usecase.ErrCourseFull), errors.Is(err, usecase.ErrEnrolledTooManyCourses):
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrStudentAlreadyEnrolled:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCouponNotValid, err == usecase.ErrCouponNotApplicable:
+			helper.MessageResponse(res, req, http.StatusBadRequest, err.Error())
+		case err == usecase.ErrCouponExhausted:
+			helper.MessageResponse(res, req, http.StatusConflict, err.Error())
+		case err == usecase.ErrCourseNotFound, err == usecase.ErrOfferingNotFound, err == usecase.ErrStudentNotFound, err == usecase.ErrEnrollmentNotFound,
+			err == usecase.ErrCouponNotFound:
+			helper.MessageResponse(res, req, http.StatusNotFound, err.Error())
+		default:
+			helper.MessageResponse(res, req, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	helper.WriteJSON(res, http.StatusOK, enrollment, nil)
+}
@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/felipedavid/vrcursos/src/core/domain"
+)
+
+// anonymousActor is recorded when nobody authenticated the request
+const anonymousActor = "anonymous"
+
+// SetActor attributes the changes made by the request to the administrator
+// authenticated by Authenticate, which must run before it. Nothing the client
+// sends besides its token is trusted to say who it is.
+func SetActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := domain.AdminFromContext(r.Context())
+		if !ok {
+			actor = anonymousActor
+		}
+
+		next.ServeHTTP(w, r.WithContext(domain.WithActor(r.Context(), actor)))
+	})
+}
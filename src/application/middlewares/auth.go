@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/felipedavid/vrcursos/src/core/domain"
+	"github.com/felipedavid/vrcursos/src/core/helper"
+)
+
+// Authenticate checks the bearer token of the request against the tokens of
+// the administrators, keyed by their name. Requests with a known token are
+// marked as sent by that administrator, requests without a token go on
+// unauthenticated and requests with an unknown one are rejected.
+func Authenticate(adminTokens map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				helper.MessageResponse(w, r, http.StatusUnauthorized, "authorization must be a bearer token")
+				return
+			}
+
+			name, ok := adminName(adminTokens, token)
+			if !ok {
+				helper.MessageResponse(w, r, http.StatusUnauthorized, "invalid token")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(domain.WithAdmin(r.Context(), name)))
+		})
+	}
+}
+
+// adminName returns the administrator holding the token. Every token is
+// compared in constant time, so the comparison doesn't leak how close a guess
+// was.
+func adminName(adminTokens map[string]string, token string) (string, bool) {
+	found := ""
+	for name, adminToken := range adminTokens {
+		if subtle.ConstantTimeCompare([]byte(adminToken), []byte(token)) == 1 {
+			found = name
+		}
+	}
+
+	return found, found != ""
+}
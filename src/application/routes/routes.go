@@ -7,7 +7,7 @@ import (
 	"github.com/felipedavid/vrcursos/src/application/middlewares"
 )
 
-func DefineRoutes(userControllers *controllers.StudentController, courseControllers *controllers.CourseController) http.Handler {
+func DefineRoutes(userControllers *controllers.StudentController, courseControllers *controllers.CourseController, gradeControllers *controllers.GradeController, attendanceControllers *controllers.AttendanceController, certificateControllers *controllers.CertificateController, instructorControllers *controllers.InstructorController, roomControllers *controllers.RoomController, categoryControllers *controllers.CategoryController, paymentControllers *controllers.PaymentController, couponControllers *controllers.CouponController, scholarshipControllers *controllers.ScholarshipController, adminTokens map[string]string) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /students", userControllers.StudentList)
@@ -15,18 +15,103 @@ func DefineRoutes(userControllers *controllers.StudentController, courseControll
 	mux.HandleFunc("POST /students", userControllers.StudentCreate)
 	mux.HandleFunc("PUT /students/{id}", userControllers.StudentUpdate)
 	mux.HandleFunc("DELETE /students/{id}", userControllers.StudentDelete)
+	mux.HandleFunc("POST /students/{id}/restore", userControllers.StudentRestore)
+	mux.HandleFunc("GET /students/{id}/courses", userControllers.StudentCourses)
+	mux.HandleFunc("POST /students/{id}/transfers", userControllers.StudentTransfer)
+
+	mux.HandleFunc("PUT /admin/students/{id}/enrollment-limit", userControllers.EnrollmentLimitSet)
+	mux.HandleFunc("DELETE /admin/students/{id}/enrollment-limit", userControllers.EnrollmentLimitClear)
+
+	mux.HandleFunc("GET /instructors", instructorControllers.InstructorList)
+	mux.HandleFunc("GET /instructors/{id}", instructorControllers.InstructorGet)
+	mux.HandleFunc("POST /instructors", instructorControllers.InstructorCreate)
+	mux.HandleFunc("PUT /instructors/{id}", instructorControllers.InstructorUpdate)
+	mux.HandleFunc("DELETE /instructors/{id}", instructorControllers.InstructorDelete)
+	mux.HandleFunc("GET /instructors/{id}/courses", instructorControllers.InstructorCourses)
 
 	mux.HandleFunc("GET /courses", courseControllers.CourseList)
 	mux.HandleFunc("GET /courses/{id}", courseControllers.CourseGet)
 	mux.HandleFunc("POST /courses", courseControllers.CourseCreate)
 	mux.HandleFunc("PUT /courses/{id}", courseControllers.CourseUpdate)
 	mux.HandleFunc("DELETE /courses/{id}", courseControllers.CourseDelete)
+	mux.HandleFunc("POST /courses/{id}/restore", courseControllers.CourseRestore)
+	mux.HandleFunc("POST /courses/{id}/publish", courseControllers.CoursePublish)
+	mux.HandleFunc("POST /courses/{id}/archive", courseControllers.CourseArchive)
+	mux.HandleFunc("GET /courses/{id}/students", courseControllers.CourseStudents)
+
+	mux.HandleFunc("PUT /courses/{id}/instructors/{instructorID}", instructorControllers.InstructorAssign)
+	mux.HandleFunc("DELETE /courses/{id}/instructors/{instructorID}", instructorControllers.InstructorUnassign)
+
+	mux.HandleFunc("GET /courses/{id}/prerequisites", courseControllers.PrerequisiteList)
+	mux.HandleFunc("POST /courses/{id}/prerequisites/{prerequisiteID}", courseControllers.PrerequisiteAdd)
+	mux.HandleFunc("DELETE /courses/{id}/prerequisites/{prerequisiteID}", courseControllers.PrerequisiteRemove)
+
+	mux.HandleFunc("GET /courses/{id}/slots", courseControllers.SlotList)
+	mux.HandleFunc("POST /courses/{id}/slots", courseControllers.SlotAdd)
+	mux.HandleFunc("DELETE /courses/{id}/slots/{slotID}", courseControllers.SlotRemove)
+
+	mux.HandleFunc("GET /categories", categoryControllers.CategoryList)
+	mux.HandleFunc("GET /categories/{id}", categoryControllers.CategoryGet)
+	mux.HandleFunc("POST /categories", categoryControllers.CategoryCreate)
+	mux.HandleFunc("PUT /categories/{id}", categoryControllers.CategoryUpdate)
+	mux.HandleFunc("DELETE /categories/{id}", categoryControllers.CategoryDelete)
+
+	mux.HandleFunc("GET /courses/{id}/offerings", courseControllers.OfferingList)
+	mux.HandleFunc("POST /courses/{id}/offerings", courseControllers.OfferingCreate)
+	mux.HandleFunc("GET /offerings/{id}", courseControllers.OfferingGet)
+	mux.HandleFunc("PUT /offerings/{id}", courseControllers.OfferingUpdate)
+	mux.HandleFunc("GET /offerings/{id}/students", courseControllers.OfferingStudents)
+
+	mux.HandleFunc("PUT /courses/{id}/slots/{slotID}/room", roomControllers.RoomBook)
+	mux.HandleFunc("DELETE /courses/{id}/slots/{slotID}/room", roomControllers.RoomRelease)
+
+	mux.HandleFunc("GET /rooms", roomControllers.RoomList)
+	mux.HandleFunc("GET /rooms/{id}", roomControllers.RoomGet)
+	mux.HandleFunc("POST /rooms", roomControllers.RoomCreate)
+	mux.HandleFunc("PUT /rooms/{id}", roomControllers.RoomUpdate)
+	mux.HandleFunc("DELETE /rooms/{id}", roomControllers.RoomDelete)
+	mux.HandleFunc("GET /rooms/{id}/timetable", roomControllers.RoomTimetable)
+
+	mux.HandleFunc("GET /courses/{id}/sessions", attendanceControllers.SessionList)
+	mux.HandleFunc("POST /courses/{id}/sessions", attendanceControllers.SessionCreate)
+	mux.HandleFunc("GET /courses/{id}/sessions/{sessionID}/attendance", attendanceControllers.AttendanceGet)
+	mux.HandleFunc("PUT /courses/{id}/sessions/{sessionID}/attendance", attendanceControllers.AttendanceSubmit)
 
 	mux.HandleFunc("POST /enroll/student/{studentID}/course/{courseID}", courseControllers.EnrollStudent)
+	mux.HandleFunc("POST /enroll/student/{studentID}/offering/{offeringID}", courseControllers.EnrollStudentInOffering)
 	mux.HandleFunc("DELETE /enroll/student/{studentID}/course/{courseID}", courseControllers.UnenrollStudent)
+	mux.HandleFunc("PUT /enroll/student/{studentID}/course/{courseID}/status", courseControllers.EnrollmentStatusUpdate)
+	mux.HandleFunc("POST /courses/{id}/enrollments:bulk", courseControllers.BulkEnroll)
+
+	mux.HandleFunc("GET /enroll/student/{studentID}/course/{courseID}/grade", gradeControllers.GradeGet)
+	mux.HandleFunc("PUT /enroll/student/{studentID}/course/{courseID}/grade", gradeControllers.GradeSet)
+	mux.HandleFunc("GET /students/{id}/transcript", gradeControllers.Transcript)
+
+	mux.HandleFunc("GET /enroll/student/{studentID}/course/{courseID}/certificate", certificateControllers.CertificateDownload)
+	mux.HandleFunc("GET /certificates/{code}", certificateControllers.CertificateVerify)
+
+	mux.HandleFunc("GET /coupons", couponControllers.CouponList)
+	mux.HandleFunc("GET /coupons/{id}", couponControllers.CouponGet)
+	mux.HandleFunc("POST /coupons", couponControllers.CouponCreate)
+	mux.HandleFunc("PUT /coupons/{id}", couponControllers.CouponUpdate)
+	mux.HandleFunc("DELETE /coupons/{id}", couponControllers.CouponDelete)
+
+	mux.HandleFunc("GET /students/{id}/scholarships", scholarshipControllers.ScholarshipList)
+	mux.HandleFunc("POST /students/{id}/scholarships", scholarshipControllers.ScholarshipCreate)
+	mux.HandleFunc("DELETE /students/{id}/scholarships/{scholarshipID}", scholarshipControllers.ScholarshipDelete)
+
+	mux.HandleFunc("POST /payments/callback", paymentControllers.PaymentCallback)
+	mux.HandleFunc("GET /invoices/{id}", paymentControllers.InvoiceGet)
+	mux.HandleFunc("GET /students/{id}/invoices", paymentControllers.StudentInvoices)
+
+	mux.HandleFunc("GET /courses/{id}/waitlist", courseControllers.WaitlistGet)
+	mux.HandleFunc("POST /waitlist/student/{studentID}/course/{courseID}", courseControllers.WaitlistJoin)
+	mux.HandleFunc("DELETE /waitlist/student/{studentID}/course/{courseID}", courseControllers.WaitlistLeave)
 
 	var handler http.Handler = mux
 
+	handler = middlewares.SetActor(handler)
+	handler = middlewares.Authenticate(adminTokens)(handler)
 	handler = middlewares.LogRequest(handler)
 
 	return handler
@@ -0,0 +1,45 @@
+package domain
+
+import "context"
+
+// SystemActor is recorded for changes no user asked for directly, like
+// promotions from the waitlist
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// WithActor stores who is performing the request in ctx
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns who is performing the request, or SystemActor when
+// nobody was set
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+type adminKey struct{}
+
+// WithAdmin marks the request as sent by the authenticated administrator with
+// the given name
+func WithAdmin(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, adminKey{}, name)
+}
+
+// AdminFromContext returns the name of the authenticated administrator who
+// sent the request, if any
+func AdminFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(adminKey{}).(string)
+	return name, ok && name != ""
+}
+
+// IsAdmin tells whether the request was sent by an authenticated
+// administrator
+func IsAdmin(ctx context.Context) bool {
+	_, ok := AdminFromContext(ctx)
+	return ok
+}
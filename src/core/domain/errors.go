@@ -3,5 +3,8 @@ package domain
 import "errors"
 
 var (
-	ErrStudentNotFound = errors.New("student not found")
+	ErrStudentNotFound        = errors.New("student not found")
+	ErrEnrollmentWindowClosed = errors.New("course is not accepting enrollment changes at this time")
+	ErrWindowOverrideDenied   = errors.New("only administrators can override the enrollment window")
+	ErrAdminRequired          = errors.New("only administrators can perform this action")
 )
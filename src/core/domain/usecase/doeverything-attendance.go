@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type CreateSessionInput struct {
+	// OfferingID picks the offering the class belongs to, the default
+	// offering of the course when omitted
+	OfferingID *int      `json:"offering_id"`
+	HeldAt     time.Time `json:"held_at"`
+	Topic      string    `json:"topic"`
+}
+
+type AttendanceRecordInput struct {
+	StudentID int    `json:"student_id"`
+	Status    string `json:"status"`
+}
+
+type SubmitAttendanceInput struct {
+	Records []AttendanceRecordInput `json:"records"`
+}
+
+var ErrSessionNotFound = repository.ErrSessionNotFound
+var ErrSessionTimeRequired = errors.New("held_at is required")
+var ErrNoAttendanceRecords = errors.New("records must not be empty")
+var ErrInvalidAttendanceStatus = errors.New("status must be present, absent or excused")
+var ErrDuplicateAttendance = errors.New("a student can only appear once in the records")
+
+// ErrStudentsNotEnrolled is returned when attendance is submitted for students
+// who aren't enrolled in the offering of the session
+type ErrStudentsNotEnrolled struct {
+	StudentIDs []int
+}
+
+func (e *ErrStudentsNotEnrolled) Error() string {
+	ids := make([]string, len(e.StudentIDs))
+	for i, id := range e.StudentIDs {
+		ids[i] = fmt.Sprint(id)
+	}
+
+	return fmt.Sprintf("students are not enrolled in the offering of the session: %s", strings.Join(ids, ", "))
+}
+
+type AttendanceUsecase interface {
+	CreateSession(ctx context.Context, courseID int, input CreateSessionInput) (*model.CourseSession, error)
+	GetSessions(ctx context.Context, courseID int) ([]*model.CourseSession, error)
+	SubmitAttendance(ctx context.Context, courseID, sessionID int, input SubmitAttendanceInput) ([]*model.Attendance, error)
+	GetSessionAttendance(ctx context.Context, courseID, sessionID int) ([]*model.Attendance, error)
+}
+
+type attendanceUsecase struct {
+	attendanceRepository repository.IAttendanceRepository
+	courseRepository     repository.ICourseRepository
+	transactor           repository.ITransactor
+}
+
+func NewAttendanceUsecase(attendanceRepo repository.IAttendanceRepository, courseRepo repository.ICourseRepository, transactor repository.ITransactor) AttendanceUsecase {
+	return &attendanceUsecase{
+		attendanceRepository: attendanceRepo,
+		courseRepository:     courseRepo,
+		transactor:           transactor,
+	}
+}
+
+func (u *attendanceUsecase) CreateSession(ctx context.Context, courseID int, input CreateSessionInput) (*model.CourseSession, error) {
+	if input.HeldAt.IsZero() {
+		return nil, ErrSessionTimeRequired
+	}
+
+	_, err := u.courseRepository.GetCourse(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, err
+	}
+
+	offering, err := u.sessionOffering(ctx, courseID, input.OfferingID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &model.CourseSession{
+		CourseID:   int64(courseID),
+		OfferingID: offering.ID,
+		HeldAt:     input.HeldAt,
+		Topic:      input.Topic,
+	}
+
+	err = u.attendanceRepository.SaveSession(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// sessionOffering returns the offering a new session belongs to, which must be
+// an offering of the course
+func (u *attendanceUsecase) sessionOffering(ctx context.Context, courseID int, offeringID *int) (*model.CourseOffering, error) {
+	if offeringID == nil {
+		return u.courseRepository.GetDefaultOffering(ctx, courseID)
+	}
+
+	offering, err := u.courseRepository.GetOffering(ctx, *offeringID)
+	if err != nil {
+		return nil, err
+	}
+
+	if int(offering.CourseID) != courseID {
+		return nil, ErrOfferingNotFound
+	}
+
+	return offering, nil
+}
+
+func (u *attendanceUsecase) GetSessions(ctx context.Context, courseID int) ([]*model.CourseSession, error) {
+	_, err := u.courseRepository.GetCourse(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, err
+	}
+
+	sessions, err := u.attendanceRepository.GetSessions(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sessions == nil {
+		return []*model.CourseSession{}, nil
+	}
+
+	return sessions, nil
+}
+
+// SubmitAttendance records the attendance of the session in a single
+// transaction. Records submitted again for the same student replace the old
+// ones. If any student isn't enrolled in the offering of the session nothing
+// is recorded.
+func (u *attendanceUsecase) SubmitAttendance(ctx context.Context, courseID, sessionID int, input SubmitAttendanceInput) ([]*model.Attendance, error) {
+	if len(input.Records) == 0 {
+		return nil, ErrNoAttendanceRecords
+	}
+
+	seen := map[int]bool{}
+	for _, record := range input.Records {
+		switch record.Status {
+		case model.AttendancePresent, model.AttendanceAbsent, model.AttendanceExcused:
+		default:
+			return nil, ErrInvalidAttendanceStatus
+		}
+
+		if seen[record.StudentID] {
+			return nil, ErrDuplicateAttendance
+		}
+		seen[record.StudentID] = true
+	}
+
+	var records []*model.Attendance
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.courseRepository.LockCourse(ctx, courseID)
+		if err != nil {
+			return err
+		}
+
+		session, err := u.attendanceRepository.GetSession(ctx, courseID, sessionID)
+		if err != nil {
+			return err
+		}
+
+		var notEnrolled []int
+
+		for _, record := range input.Records {
+			enrollment, err := u.courseRepository.GetLastEnrollment(ctx, courseID, record.StudentID)
+			if err == ErrEnrollmentNotFound {
+				notEnrolled = append(notEnrolled, record.StudentID)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if enrollment.OfferingID != session.OfferingID ||
+				(enrollment.Status != model.EnrollmentStatusActive && enrollment.Status != model.EnrollmentStatusCompleted) {
+				notEnrolled = append(notEnrolled, record.StudentID)
+				continue
+			}
+
+			attendance := &model.Attendance{
+				SessionID:    int64(sessionID),
+				EnrollmentID: enrollment.ID,
+				StudentID:    int64(record.StudentID),
+				Status:       record.Status,
+			}
+
+			err = u.attendanceRepository.SaveAttendance(ctx, attendance)
+			if err != nil {
+				return err
+			}
+
+			records = append(records, attendance)
+		}
+
+		if len(notEnrolled) > 0 {
+			return &ErrStudentsNotEnrolled{StudentIDs: notEnrolled}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (u *attendanceUsecase) GetSessionAttendance(ctx context.Context, courseID, sessionID int) ([]*model.Attendance, error) {
+	_, err := u.attendanceRepository.GetSession(ctx, courseID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := u.attendanceRepository.GetSessionAttendance(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if records == nil {
+		return []*model.Attendance{}, nil
+	}
+
+	return records, nil
+}
+
+// evaluateAttendance fills the percentage and risk flag of every summary,
+// handing an empty one to the ids without attendance records
+func evaluateAttendance(summaries map[int64]*model.AttendanceSummary, ids []int64, minAttendance float64) map[int64]*model.AttendanceSummary {
+	for _, id := range ids {
+		if _, ok := summaries[id]; !ok {
+			summaries[id] = &model.AttendanceSummary{}
+		}
+	}
+
+	for _, summary := range summaries {
+		summary.Evaluate(minAttendance)
+	}
+
+	return summaries
+}
@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type CreateCategoryInput struct {
+	Name     string `json:"name"`
+	ParentID *int64 `json:"parent_id"`
+}
+
+type UpdateCategoryInput struct {
+	Name     string `json:"name"`
+	ParentID *int64 `json:"parent_id"`
+}
+
+var ErrCategoryNotFound = repository.ErrCategoryNotFound
+var ErrCategoryNameRequired = errors.New("name is required")
+var ErrCategoryCycle = errors.New("a category can't be moved under itself or one of its subcategories")
+var ErrInvalidReassignTarget = errors.New("reassign_to must be a different category")
+var ErrInvalidTag = errors.New("tags must not be empty")
+
+// ErrCategoryHasCourses is returned when deleting a category that still has
+// courses without saying where they should go
+type ErrCategoryHasCourses struct {
+	Courses int
+}
+
+func (e *ErrCategoryHasCourses) Error() string {
+	return fmt.Sprintf("category still has %d courses, choose a category to move them to with reassign_to", e.Courses)
+}
+
+// normalizeTags lowercases the tags and drops duplicates, tags are compared
+// case insensitively
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			return nil, ErrInvalidTag
+		}
+
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	slices.Sort(normalized)
+
+	return normalized, nil
+}
+
+type CategoryUsecase interface {
+	CreateCategory(ctx context.Context, input CreateCategoryInput) (*model.Category, error)
+	GetCategory(ctx context.Context, id int) (*model.Category, error)
+	GetCategories(ctx context.Context) ([]*model.Category, error)
+	UpdateCategory(ctx context.Context, id int, input UpdateCategoryInput) (*model.Category, error)
+	DeleteCategory(ctx context.Context, id int, reassignTo *int) error
+}
+
+type categoryUsecase struct {
+	categoryRepository repository.ICategoryRepository
+	transactor         repository.ITransactor
+}
+
+func NewCategoryUsecase(repo repository.ICategoryRepository, transactor repository.ITransactor) CategoryUsecase {
+	return &categoryUsecase{
+		categoryRepository: repo,
+		transactor:         transactor,
+	}
+}
+
+func (u *categoryUsecase) CreateCategory(ctx context.Context, input CreateCategoryInput) (*model.Category, error) {
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, ErrCategoryNameRequired
+	}
+
+	category := &model.Category{
+		Name:     input.Name,
+		ParentID: input.ParentID,
+	}
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if category.ParentID != nil {
+			_, err := u.categoryRepository.GetCategory(ctx, int(*category.ParentID))
+			if err != nil {
+				return err
+			}
+		}
+
+		err := u.categoryRepository.Save(ctx, category)
+		if err != nil {
+			return err
+		}
+
+		category, err = u.categoryRepository.GetCategory(ctx, int(category.ID))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (u *categoryUsecase) GetCategory(ctx context.Context, id int) (*model.Category, error) {
+	return u.categoryRepository.GetCategory(ctx, id)
+}
+
+func (u *categoryUsecase) GetCategories(ctx context.Context) ([]*model.Category, error) {
+	categories, err := u.categoryRepository.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if categories == nil {
+		return []*model.Category{}, nil
+	}
+
+	return categories, nil
+}
+
+// UpdateCategory renames the category or moves it under another parent. The
+// whole hierarchy is locked so concurrent moves can't form a cycle.
+func (u *categoryUsecase) UpdateCategory(ctx context.Context, id int, input UpdateCategoryInput) (*model.Category, error) {
+	if strings.TrimSpace(input.Name) == "" {
+		return nil, ErrCategoryNameRequired
+	}
+
+	var category *model.Category
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.categoryRepository.LockCategories(ctx)
+		if err != nil {
+			return err
+		}
+
+		category, err = u.categoryRepository.GetCategory(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if input.ParentID != nil {
+			_, err := u.categoryRepository.GetCategory(ctx, int(*input.ParentID))
+			if err != nil {
+				return err
+			}
+
+			cycle, err := u.categoryRepository.IsDescendant(ctx, int(*input.ParentID), id)
+			if err != nil {
+				return err
+			}
+
+			if cycle {
+				return ErrCategoryCycle
+			}
+		}
+
+		category.Name = input.Name
+		category.ParentID = input.ParentID
+
+		err = u.categoryRepository.UpdateCategory(ctx, category)
+		if err != nil {
+			return err
+		}
+
+		category, err = u.categoryRepository.GetCategory(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// DeleteCategory removes the category. Its subcategories move up to its
+// parent, and its courses move to reassignTo, which is required when the
+// category still has courses.
+func (u *categoryUsecase) DeleteCategory(ctx context.Context, id int, reassignTo *int) error {
+	return u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.categoryRepository.LockCategories(ctx)
+		if err != nil {
+			return err
+		}
+
+		category, err := u.categoryRepository.GetCategory(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		courses, err := u.categoryRepository.CountCourses(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if courses > 0 {
+			if reassignTo == nil {
+				return &ErrCategoryHasCourses{Courses: courses}
+			}
+
+			if *reassignTo == id {
+				return ErrInvalidReassignTarget
+			}
+
+			_, err = u.categoryRepository.GetCategory(ctx, *reassignTo)
+			if err != nil {
+				return err
+			}
+
+			err = u.categoryRepository.ReassignCourses(ctx, id, *reassignTo)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = u.categoryRepository.ReparentChildren(ctx, id, category.ParentID)
+		if err != nil {
+			return err
+		}
+
+		return u.categoryRepository.DeleteCategory(ctx, id)
+	})
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/felipedavid/vrcursos/src/core/domain"
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+var ErrEnrollmentNotCompleted = errors.New("enrollment is not completed")
+var ErrInvalidCertificate = errors.New("certificate not found or invalid")
+
+type CertificateUsecase interface {
+	GetCertificate(ctx context.Context, courseID, studentID int) (*model.Certificate, error)
+	VerifyCertificate(ctx context.Context, code string) (*model.Certificate, error)
+}
+
+type certificateUsecase struct {
+	courseRepository  repository.ICourseRepository
+	studentRepository repository.IStudentRepository
+	// key signs the verification codes
+	key []byte
+}
+
+func NewCertificateUsecase(courseRepo repository.ICourseRepository, studentRepo repository.IStudentRepository, key []byte) CertificateUsecase {
+	return &certificateUsecase{
+		courseRepository:  courseRepo,
+		studentRepository: studentRepo,
+		key:               key,
+	}
+}
+
+// GetCertificate returns the certificate of the latest enrollment of the
+// student in the course, which must be completed
+func (u *certificateUsecase) GetCertificate(ctx context.Context, courseID, studentID int) (*model.Certificate, error) {
+	enrollment, err := u.courseRepository.GetLastEnrollment(ctx, courseID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if enrollment.Status != model.EnrollmentStatusCompleted {
+		return nil, ErrEnrollmentNotCompleted
+	}
+
+	return u.certificate(ctx, enrollment)
+}
+
+// VerifyCertificate checks the signature of the code and returns the
+// certificate it was issued for. Codes of enrollments that are no longer
+// completed are rejected as well.
+func (u *certificateUsecase) VerifyCertificate(ctx context.Context, code string) (*model.Certificate, error) {
+	enrollmentID, ok := u.verify(code)
+	if !ok {
+		return nil, ErrInvalidCertificate
+	}
+
+	enrollment, err := u.courseRepository.GetEnrollmentByID(ctx, enrollmentID)
+	if err != nil {
+		if err == repository.ErrEnrollmentNotFound {
+			return nil, ErrInvalidCertificate
+		}
+		return nil, err
+	}
+
+	if enrollment.Status != model.EnrollmentStatusCompleted {
+		return nil, ErrInvalidCertificate
+	}
+
+	return u.certificate(ctx, enrollment)
+}
+
+// certificate builds the certificate of the enrollment. Deleting the student
+// or the course doesn't take back the certificates they were issued, so
+// deleted ones are looked up as well.
+func (u *certificateUsecase) certificate(ctx context.Context, enrollment *model.Enrollment) (*model.Certificate, error) {
+	student, err := u.studentRepository.GetStudentIncludingDeleted(ctx, int(enrollment.StudentID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrStudentNotFound
+		}
+		return nil, err
+	}
+
+	course, err := u.courseRepository.GetCourseIncludingDeleted(ctx, int(enrollment.CourseID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, err
+	}
+
+	certificate := &model.Certificate{
+		Code:        u.sign(enrollment.ID),
+		StudentID:   student.ID,
+		StudentName: student.Name,
+		CourseID:    course.ID,
+		CourseName:  course.Name,
+	}
+
+	if enrollment.CompletedAt != nil {
+		certificate.CompletedAt = *enrollment.CompletedAt
+	}
+
+	return certificate, nil
+}
+
+// certificateEncoding keeps the codes short and easy to type
+var certificateEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// sign builds the verification code of an enrollment, made of its id and a
+// truncated HMAC-SHA256 of it
+func (u *certificateUsecase) sign(enrollmentID int64) string {
+	return fmt.Sprintf("%d-%s", enrollmentID, certificateEncoding.EncodeToString(u.mac(enrollmentID)))
+}
+
+// verify returns the enrollment id of the code if its signature is valid
+func (u *certificateUsecase) verify(code string) (int64, bool) {
+	id, signature, ok := strings.Cut(strings.ToUpper(code), "-")
+	if !ok {
+		return 0, false
+	}
+
+	enrollmentID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	mac, err := certificateEncoding.DecodeString(signature)
+	if err != nil {
+		return 0, false
+	}
+
+	return enrollmentID, hmac.Equal(mac, u.mac(enrollmentID))
+}
+
+func (u *certificateUsecase) mac(enrollmentID int64) []byte {
+	h := hmac.New(sha256.New, u.key)
+	fmt.Fprintf(h, "certificate:%d", enrollmentID)
+	return h.Sum(nil)[:15]
+}
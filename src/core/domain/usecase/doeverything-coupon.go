@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type CreateCouponInput struct {
+	Code           string     `json:"code"`
+	DiscountType   string     `json:"discount_type"`
+	Value          int64      `json:"value"`
+	Currency       *string    `json:"currency"`
+	MaxRedemptions *int       `json:"max_redemptions"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	CourseIDs      []int64    `json:"course_ids"`
+}
+
+// UpdateCouponInput replaces the terms of a coupon, its code can't be changed
+type UpdateCouponInput struct {
+	DiscountType   string     `json:"discount_type"`
+	Value          int64      `json:"value"`
+	Currency       *string    `json:"currency"`
+	MaxRedemptions *int       `json:"max_redemptions"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	CourseIDs      []int64    `json:"course_ids"`
+}
+
+var ErrCouponNotFound = repository.ErrCouponNotFound
+var ErrCouponCodeTaken = repository.ErrCouponCodeTaken
+var ErrCouponExhausted = repository.ErrCouponExhausted
+var ErrCouponCapBelowRedemptions = repository.ErrCouponCapBelowRedemptions
+var ErrCouponCodeRequired = errors.New("code is required")
+var ErrInvalidDiscountType = errors.New("discount_type must be either percent or fixed")
+var ErrInvalidPercentDiscount = errors.New("value of a percent coupon must be between 1 and 100")
+var ErrInvalidFixedDiscount = errors.New("value of a fixed coupon must be greater than zero and come with a currency, like BRL")
+var ErrInvalidMaxRedemptions = errors.New("max_redemptions must be greater than zero")
+var ErrInvalidValidity = errors.New("valid_from must be before valid_until")
+var ErrCouponNotValid = errors.New("coupon is not valid at this time")
+var ErrCouponNotApplicable = errors.New("coupon does not apply to this course")
+
+// validateDiscount checks the terms of a coupon and returns its currency,
+// which only fixed coupons have
+func validateDiscount(discountType string, value int64, currency *string) (*string, error) {
+	switch discountType {
+	case model.DiscountTypePercent:
+		if value < 1 || value > 100 {
+			return nil, ErrInvalidPercentDiscount
+		}
+		return nil, nil
+	case model.DiscountTypeFixed:
+		if value <= 0 || currency == nil || !validCurrency(*currency) {
+			return nil, ErrInvalidFixedDiscount
+		}
+		return currency, nil
+	default:
+		return nil, ErrInvalidDiscountType
+	}
+}
+
+func validateValidity(validFrom, validUntil *time.Time) error {
+	if validFrom != nil && validUntil != nil && !validFrom.Before(*validUntil) {
+		return ErrInvalidValidity
+	}
+
+	return nil
+}
+
+type CouponUsecase interface {
+	CreateCoupon(ctx context.Context, input CreateCouponInput) (*model.Coupon, error)
+	GetCoupons(ctx context.Context) ([]*model.Coupon, error)
+	GetCoupon(ctx context.Context, id int) (*model.Coupon, error)
+	UpdateCoupon(ctx context.Context, id int, input UpdateCouponInput) (*model.Coupon, error)
+	DeleteCoupon(ctx context.Context, id int) error
+}
+
+type couponUsecase struct {
+	couponRepository repository.ICouponRepository
+	transactor       repository.ITransactor
+}
+
+func NewCouponUsecase(deps Deps) CouponUsecase {
+	return &couponUsecase{
+		couponRepository: deps.CouponRepository,
+		transactor:       deps.Transactor,
+	}
+}
+
+func (u *couponUsecase) CreateCoupon(ctx context.Context, input CreateCouponInput) (*model.Coupon, error) {
+	code := normalizeCouponCode(input.Code)
+	if code == "" {
+		return nil, ErrCouponCodeRequired
+	}
+
+	coupon := &model.Coupon{
+		Code:           code,
+		DiscountType:   input.DiscountType,
+		Value:          input.Value,
+		MaxRedemptions: input.MaxRedemptions,
+		ValidFrom:      input.ValidFrom,
+		ValidUntil:     input.ValidUntil,
+	}
+
+	err := u.validate(coupon, input.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.couponRepository.SaveCoupon(ctx, coupon)
+		if err != nil {
+			return err
+		}
+
+		err = u.couponRepository.SetCouponCourses(ctx, coupon.ID, uniqueIDs(input.CourseIDs))
+		if err != nil {
+			return err
+		}
+
+		coupon, err = u.couponRepository.GetCoupon(ctx, int(coupon.ID))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+// validate checks the terms of the coupon, setting its currency
+func (u *couponUsecase) validate(coupon *model.Coupon, currency *string) error {
+	currency, err := validateDiscount(coupon.DiscountType, coupon.Value, currency)
+	if err != nil {
+		return err
+	}
+	coupon.Currency = currency
+
+	if coupon.MaxRedemptions != nil && *coupon.MaxRedemptions <= 0 {
+		return ErrInvalidMaxRedemptions
+	}
+
+	return validateValidity(coupon.ValidFrom, coupon.ValidUntil)
+}
+
+// uniqueIDs drops the repeated ids
+func uniqueIDs(ids []int64) []int64 {
+	unique := []int64{}
+
+	for _, id := range ids {
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
+}
+
+func (u *couponUsecase) GetCoupons(ctx context.Context) ([]*model.Coupon, error) {
+	coupons, err := u.couponRepository.GetCoupons(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if coupons == nil {
+		return []*model.Coupon{}, nil
+	}
+
+	return coupons, nil
+}
+
+func (u *couponUsecase) GetCoupon(ctx context.Context, id int) (*model.Coupon, error) {
+	return u.couponRepository.GetCoupon(ctx, id)
+}
+
+// UpdateCoupon replaces the terms of the coupon. Enrollments that already
+// redeemed it keep the discount they got.
+func (u *couponUsecase) UpdateCoupon(ctx context.Context, id int, input UpdateCouponInput) (*model.Coupon, error) {
+	var coupon *model.Coupon
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		coupon, err = u.couponRepository.GetCoupon(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		coupon.DiscountType = input.DiscountType
+		coupon.Value = input.Value
+		coupon.MaxRedemptions = input.MaxRedemptions
+		coupon.ValidFrom = input.ValidFrom
+		coupon.ValidUntil = input.ValidUntil
+
+		err = u.validate(coupon, input.Currency)
+		if err != nil {
+			return err
+		}
+
+		err = u.couponRepository.UpdateCoupon(ctx, coupon)
+		if err != nil {
+			return err
+		}
+
+		err = u.couponRepository.SetCouponCourses(ctx, coupon.ID, uniqueIDs(input.CourseIDs))
+		if err != nil {
+			return err
+		}
+
+		coupon, err = u.couponRepository.GetCoupon(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (u *couponUsecase) DeleteCoupon(ctx context.Context, id int) error {
+	return u.couponRepository.DeleteCoupon(ctx, id)
+}
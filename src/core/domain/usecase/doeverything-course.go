@@ -2,58 +2,230 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/felipedavid/vrcursos/src/core/domain"
 	"github.com/felipedavid/vrcursos/src/core/model"
 	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
 )
 
+// DefaultCourseCapacity is used when an offering is created without a capacity
+const DefaultCourseCapacity = 10
+
+// DefaultCurrency is used when a course is created without a currency
+const DefaultCurrency = "BRL"
+
+// CreateCourseInput creates the course along with its default offering, which
+// takes the capacity and the enrollment window
 type CreateCourseInput struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name               string     `json:"name"`
+	Description        string     `json:"description"`
+	Capacity           int        `json:"capacity"`
+	EnrollmentOpensAt  *time.Time `json:"enrollment_opens_at"`
+	EnrollmentClosesAt *time.Time `json:"enrollment_closes_at"`
+	CategoryID         *int64     `json:"category_id"`
+	Tags               []string   `json:"tags"`
+	Price              int64      `json:"price"`
+	Currency           string     `json:"currency"`
 }
 
 type UpdateCourseInput struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	CategoryID  *int64   `json:"category_id"`
+	Tags        []string `json:"tags"`
+	// Price and Currency are left untouched when omitted
+	Price    *int64  `json:"price"`
+	Currency *string `json:"currency"`
 }
 
 type GetCourseOutput struct {
-	ID              int    `json:"id"`
-	Name            string `json:"name"`
-	Description     string `json:"description"`
-	HowManyEnrolled int    `json:"how_many_enrolled"`
+	ID          int                       `json:"id"`
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	CategoryID  *int64                    `json:"category_id"`
+	Tags        []string                  `json:"tags"`
+	Price       int64                     `json:"price"`
+	Currency    string                    `json:"currency"`
+	Status      string                    `json:"status"`
+	PublishedAt *time.Time                `json:"published_at,omitempty"`
+	ArchivedAt  *time.Time                `json:"archived_at,omitempty"`
+	DeletedAt   *time.Time                `json:"deleted_at,omitempty"`
+	Offerings   []*GetOfferingOutput      `json:"offerings"`
+	Instructors []*model.CourseInstructor `json:"instructors"`
+}
+
+func validateEnrollmentWindow(opensAt, closesAt *time.Time) error {
+	if opensAt != nil && closesAt != nil && !opensAt.Before(*closesAt) {
+		return ErrInvalidEnrollmentWindow
+	}
+
+	return nil
+}
+
+// validatePrice checks the price of a course and returns its currency, which
+// defaults to DefaultCurrency
+func validatePrice(price int64, currency string) (string, error) {
+	if price < 0 {
+		return "", ErrInvalidPrice
+	}
+
+	if currency == "" {
+		return DefaultCurrency, nil
+	}
+
+	if !validCurrency(currency) {
+		return "", ErrInvalidCurrency
+	}
+
+	return currency, nil
+}
+
+// validCurrency reports whether the currency looks like an ISO 4217 code,
+// three uppercase letters
+func validCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+
+	return true
 }
 
 type CourseUsecase interface {
 	CreateCourse(ctx context.Context, input CreateCourseInput) (*model.Course, error)
-	GetCourse(ctx context.Context, id int) (*GetCourseOutput, error)
-	GetCourses(ctx context.Context) ([]*GetCourseOutput, error)
+	GetCourse(ctx context.Context, id int, includeDeleted bool) (*GetCourseOutput, error)
+	GetCourses(ctx context.Context, filter repository.CourseFilter) ([]*GetCourseOutput, error)
 	UpdateCourse(ctx context.Context, id int, input UpdateCourseInput) (*model.Course, error)
-	DeleteCourse(ctx context.Context, id int) error
-	EnrollStudent(ctx context.Context, courseID, studentID int) error
-	UnenrollStudent(ctx context.Context, courseID, studentID int) error
+	PublishCourse(ctx context.Context, id int) (*model.Course, error)
+	ArchiveCourse(ctx context.Context, id int) (*model.Course, error)
+	DeleteCourse(ctx context.Context, id int, cascade bool) error
+	RestoreCourse(ctx context.Context, id int) (*model.Course, error)
+	PurgeCourses(ctx context.Context, deletedBefore time.Time) (int, error)
+	EnrollStudent(ctx context.Context, courseID, studentID int, opts EnrollmentOptions) (*EnrollStudentOutput, error)
+	UnenrollStudent(ctx context.Context, courseID, studentID int, opts EnrollmentOptions) error
+	GetWaitlist(ctx context.Context, courseID int) ([]*model.WaitlistEntry, error)
+	JoinWaitlist(ctx context.Context, courseID, studentID int) (int, error)
+	LeaveWaitlist(ctx context.Context, courseID, studentID int) error
+	ChangeEnrollmentStatus(ctx context.Context, courseID, studentID int, input ChangeEnrollmentStatusInput) (*model.Enrollment, error)
+	GetPrerequisites(ctx context.Context, courseID int) ([]*model.Course, error)
+	AddPrerequisite(ctx context.Context, courseID, prerequisiteID int) error
+	RemovePrerequisite(ctx context.Context, courseID, prerequisiteID int) error
+	GetSlots(ctx context.Context, courseID int) ([]*model.CourseSlot, error)
+	AddSlot(ctx context.Context, courseID int, input AddSlotInput) (*model.CourseSlot, error)
+	RemoveSlot(ctx context.Context, courseID, slotID int) error
+	BulkEnroll(ctx context.Context, courseID int, input BulkEnrollInput, opts EnrollmentOptions) (*BulkEnrollOutput, error)
+	GetCourseStudents(ctx context.Context, courseID int, search string, asOf *time.Time) ([]*CourseStudentOutput, error)
+	CreateOffering(ctx context.Context, courseID int, input CreateOfferingInput) (*model.CourseOffering, error)
+	GetOfferings(ctx context.Context, courseID int) ([]*GetOfferingOutput, error)
+	GetOffering(ctx context.Context, id int) (*GetOfferingOutput, error)
+	UpdateOffering(ctx context.Context, id int, input UpdateOfferingInput) (*model.CourseOffering, error)
+	GetOfferingStudents(ctx context.Context, id int, search string) ([]*CourseStudentOutput, error)
+	EnrollStudentInOffering(ctx context.Context, offeringID, studentID int, opts EnrollmentOptions) (*EnrollStudentOutput, error)
 }
 
 type courseUsecase struct {
-	courseRepository  repository.ICourseRepository
-	studentRepository repository.IStudentRepository
+	courseRepository     repository.ICourseRepository
+	studentRepository    repository.IStudentRepository
+	waitlistRepository   repository.IWaitlistRepository
+	attendanceRepository repository.IAttendanceRepository
+	instructorRepository repository.IInstructorRepository
+	roomRepository       repository.IRoomRepository
+	categoryRepository   repository.ICategoryRepository
+	invoiceRepository    repository.IInvoiceRepository
+	transactor           repository.ITransactor
+	enroller             *enroller
+	paymentTasks         *paymentTasks
+	// minAttendance is the attendance percentage below which students are
+	// flagged as at risk
+	minAttendance float64
 }
 
-func NewCourseUsecase(courseRepo repository.ICourseRepository, studentRepo repository.IStudentRepository) CourseUsecase {
+func NewCourseUsecase(deps Deps, config Config) CourseUsecase {
 	return &courseUsecase{
-		courseRepository:  courseRepo,
-		studentRepository: studentRepo,
+		courseRepository:     deps.CourseRepository,
+		studentRepository:    deps.StudentRepository,
+		waitlistRepository:   deps.WaitlistRepository,
+		attendanceRepository: deps.AttendanceRepository,
+		instructorRepository: deps.InstructorRepository,
+		roomRepository:       deps.RoomRepository,
+		categoryRepository:   deps.CategoryRepository,
+		invoiceRepository:    deps.InvoiceRepository,
+		transactor:           deps.Transactor,
+		enroller:             newEnroller(deps, config),
+		paymentTasks:         newPaymentTasks(deps),
+		minAttendance:        config.MinAttendance,
 	}
 }
 
 func (u *courseUsecase) CreateCourse(ctx context.Context, input CreateCourseInput) (*model.Course, error) {
+	if input.Capacity == 0 {
+		input.Capacity = DefaultCourseCapacity
+	}
+
+	if input.Capacity < 0 {
+		return nil, ErrInvalidCapacity
+	}
+
+	err := validateEnrollmentWindow(input.EnrollmentOpensAt, input.EnrollmentClosesAt)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := normalizeTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := validatePrice(input.Price, input.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	course := &model.Course{
 		Name:        input.Name,
 		Description: input.Description,
+		CategoryID:  input.CategoryID,
+		Tags:        tags,
+		Price:       input.Price,
+		Currency:    currency,
+		Status:      model.CourseStatusDraft,
 	}
 
-	err := u.courseRepository.Save(ctx, course)
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.checkCategory(ctx, course.CategoryID)
+		if err != nil {
+			return err
+		}
+
+		err = u.courseRepository.Save(ctx, course)
+		if err != nil {
+			return err
+		}
+
+		err = u.courseRepository.SetCourseTags(ctx, int(course.ID), course.Tags)
+		if err != nil {
+			return err
+		}
+
+		return u.courseRepository.SaveOffering(ctx, &model.CourseOffering{
+			CourseID:           course.ID,
+			Name:               DefaultOfferingName,
+			Capacity:           input.Capacity,
+			EnrollmentOpensAt:  input.EnrollmentOpensAt,
+			EnrollmentClosesAt: input.EnrollmentClosesAt,
+			IsDefault:          true,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -61,38 +233,109 @@ func (u *courseUsecase) CreateCourse(ctx context.Context, input CreateCourseInpu
 	return course, nil
 }
 
-func (u *courseUsecase) GetCourse(ctx context.Context, id int) (*GetCourseOutput, error) {
-	course, err := u.courseRepository.GetCourse(ctx, id)
+// GetCourse returns the course, deleted courses are only found when
+// includeDeleted is set
+func (u *courseUsecase) GetCourse(ctx context.Context, id int, includeDeleted bool) (*GetCourseOutput, error) {
+	var course *model.Course
+	var err error
+
+	if includeDeleted {
+		course, err = u.courseRepository.GetCourseIncludingDeleted(ctx, id)
+	} else {
+		course, err = u.courseRepository.GetCourse(ctx, id)
+	}
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourseNotFound
+		}
 		return nil, err
 	}
 
-	// TODO: Refactor this in only a single query
-	nStudentsEnrolled, err := u.courseRepository.HowManyEnrolled(ctx, id)
+	return u.newGetCourseOutput(ctx, course)
+}
+
+// newGetCourseOutput lists the offerings of the course with their seats taken
+// and the instructors assigned to it
+func (u *courseUsecase) newGetCourseOutput(ctx context.Context, course *model.Course) (*GetCourseOutput, error) {
+	offerings, err := u.getOfferings(ctx, int(course.ID))
 	if err != nil {
 		return nil, err
 	}
 
-	courseOutput := &GetCourseOutput{
-		ID:              int(course.ID),
-		Name:            course.Name,
-		Description:     course.Description,
-		HowManyEnrolled: nStudentsEnrolled,
+	instructors, err := u.instructorRepository.GetCourseInstructors(ctx, int(course.ID))
+	if err != nil {
+		return nil, err
 	}
 
-	return courseOutput, nil
+	if instructors == nil {
+		instructors = []*model.CourseInstructor{}
+	}
+
+	return &GetCourseOutput{
+		ID:          int(course.ID),
+		Name:        course.Name,
+		Description: course.Description,
+		CategoryID:  course.CategoryID,
+		Tags:        course.Tags,
+		Price:       course.Price,
+		Currency:    course.Currency,
+		Status:      course.Status,
+		PublishedAt: course.PublishedAt,
+		ArchivedAt:  course.ArchivedAt,
+		DeletedAt:   course.DeletedAt,
+		Offerings:   offerings,
+		Instructors: instructors,
+	}, nil
 }
 
+// UpdateCourse replaces the details of the course, its tags included. A new
+// price only applies to invoices created from then on.
 func (u *courseUsecase) UpdateCourse(ctx context.Context, id int, input UpdateCourseInput) (*model.Course, error) {
-	course, err := u.courseRepository.GetCourse(ctx, id)
+	tags, err := normalizeTags(input.Tags)
 	if err != nil {
 		return nil, err
 	}
 
-	course.Name = input.Name
-	course.Description = input.Description
+	var course *model.Course
 
-	err = u.courseRepository.UpdateCourse(ctx, course)
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		course, err = u.courseRepository.GetCourse(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrCourseNotFound
+			}
+			return err
+		}
+
+		course.Name = input.Name
+		course.Description = input.Description
+		course.CategoryID = input.CategoryID
+		course.Tags = tags
+
+		if input.Price != nil {
+			course.Price = *input.Price
+		}
+		if input.Currency != nil {
+			course.Currency = *input.Currency
+		}
+
+		course.Currency, err = validatePrice(course.Price, course.Currency)
+		if err != nil {
+			return err
+		}
+
+		err = u.checkCategory(ctx, course.CategoryID)
+		if err != nil {
+			return err
+		}
+
+		err = u.courseRepository.UpdateCourse(ctx, course)
+		if err != nil {
+			return err
+		}
+
+		return u.courseRepository.SetCourseTags(ctx, id, course.Tags)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -100,17 +343,210 @@ func (u *courseUsecase) UpdateCourse(ctx context.Context, id int, input UpdateCo
 	return course, nil
 }
 
-func (u *courseUsecase) DeleteCourse(ctx context.Context, id int) error {
-	err := u.courseRepository.DeleteCourse(ctx, id)
+// PublishCourse opens a draft course to enrollments, or reopens an archived
+// one. The course must have a name and a description to be published.
+func (u *courseUsecase) PublishCourse(ctx context.Context, id int) (*model.Course, error) {
+	return u.changeCourseStatus(ctx, id, func(course *model.Course) error {
+		if course.Status == model.CourseStatusPublished {
+			return ErrCourseAlreadyPublished
+		}
+
+		if strings.TrimSpace(course.Name) == "" {
+			return ErrCourseNameRequired
+		}
+
+		if strings.TrimSpace(course.Description) == "" {
+			return ErrCourseDescriptionRequired
+		}
+
+		course.Status = model.CourseStatusPublished
+		return nil
+	})
+}
+
+// ArchiveCourse closes a published course to new enrollments. Its current
+// students, history and waitlist are kept.
+func (u *courseUsecase) ArchiveCourse(ctx context.Context, id int) (*model.Course, error) {
+	return u.changeCourseStatus(ctx, id, func(course *model.Course) error {
+		if course.Status != model.CourseStatusPublished {
+			return ErrCourseNotArchivable
+		}
+
+		course.Status = model.CourseStatusArchived
+		return nil
+	})
+}
+
+// changeCourseStatus applies the transition to the course under the course
+// lock, so it can't interleave with an enrollment
+func (u *courseUsecase) changeCourseStatus(ctx context.Context, id int, transition func(course *model.Course) error) (*model.Course, error) {
+	var course *model.Course
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.courseRepository.LockCourse(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		course, err = u.courseRepository.GetCourse(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = transition(course)
+		if err != nil {
+			return err
+		}
+
+		return u.courseRepository.UpdateCourseStatus(ctx, course)
+	})
 	if err != nil {
+		return nil, err
+	}
+
+	return course, nil
+}
+
+// checkCategory makes sure the category a course is put in exists
+func (u *courseUsecase) checkCategory(ctx context.Context, categoryID *int64) error {
+	if categoryID == nil {
+		return nil
+	}
+
+	_, err := u.categoryRepository.GetCategory(ctx, int(*categoryID))
+	return err
+}
+
+// ErrCourseHasEnrollments is returned when deleting a course that still has
+// students enrolled without asking to unenroll them
+type ErrCourseHasEnrollments struct {
+	Enrollments int
+}
+
+func (e *ErrCourseHasEnrollments) Error() string {
+	return fmt.Sprintf("course still has %d active enrollments, delete it with cascade=true to unenroll them", e.Enrollments)
+}
+
+// DeleteCourse deletes the course. A course with students enrolled is only
+// deleted with cascade, which first drops every one of them, recording the
+// deletion as the reason.
+func (u *courseUsecase) DeleteCourse(ctx context.Context, id int, cascade bool) error {
+	return u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.courseRepository.LockCourse(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		students, err := u.courseRepository.GetCourseStudents(ctx, id, "")
+		if err != nil {
+			return err
+		}
+
+		if len(students) > 0 && !cascade {
+			return &ErrCourseHasEnrollments{Enrollments: len(students)}
+		}
+
+		reason := "course deleted"
+		for _, student := range students {
+			_, err = u.enroller.drop(ctx, id, int(student.ID), model.EnrollmentEventUnenroll, &reason)
+			if err != nil {
+				return err
+			}
+		}
+
+		return u.courseRepository.DeleteCourse(ctx, id)
+	})
+}
+
+// RestoreCourse brings back a deleted course in the status it had when it was
+// deleted. Deleted courses are skipped by the prerequisite cycle check, so the
+// restore is rejected when the prerequisites added meanwhile close a cycle
+// through the course.
+func (u *courseUsecase) RestoreCourse(ctx context.Context, id int) (*model.Course, error) {
+	var course *model.Course
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.courseRepository.LockPrerequisites(ctx)
+		if err != nil {
+			return err
+		}
+
+		course, err = u.courseRepository.GetCourseIncludingDeleted(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrCourseNotFound
+			}
+			return err
+		}
+
+		if course.DeletedAt == nil {
+			return ErrCourseNotDeleted
+		}
+
+		err = u.courseRepository.RestoreCourse(ctx, id)
+		if err != nil {
+			return err
+		}
+		course.DeletedAt = nil
+
+		cycle, err := u.courseRepository.RequiresTransitively(ctx, id, id)
+		if err != nil {
+			return err
+		}
+
+		if cycle {
+			return ErrPrerequisiteCycle
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return course, nil
+}
+
+// PurgeCourses permanently removes the courses deleted before deletedBefore,
+// returning how many were removed
+func (u *courseUsecase) PurgeCourses(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var purged int
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		purged, err = u.courseRepository.PurgeCourses(ctx, deletedBefore)
 		return err
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return purged, nil
 }
 
-func (u *courseUsecase) GetCourses(ctx context.Context) ([]*GetCourseOutput, error) {
-	courses, err := u.courseRepository.GetCourses(ctx)
+// GetCourses lists the courses matching the filter. Tags are matched the way
+// they are stored, lowercased. Drafts are left out unless they are asked for
+// by status.
+func (u *courseUsecase) GetCourses(ctx context.Context, filter repository.CourseFilter) ([]*GetCourseOutput, error) {
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return nil, err
+	}
+	filter.Tags = tags
+
+	for _, status := range filter.Statuses {
+		switch status {
+		case model.CourseStatusDraft, model.CourseStatusPublished, model.CourseStatusArchived:
+		default:
+			return nil, ErrInvalidCourseStatus
+		}
+	}
+
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []string{model.CourseStatusPublished, model.CourseStatusArchived}
+	}
+
+	courses, err := u.courseRepository.GetCourses(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -119,19 +555,12 @@ func (u *courseUsecase) GetCourses(ctx context.Context) ([]*GetCourseOutput, err
 
 	// TODO: Refactor this in only a single query
 	for _, course := range courses {
-		nStudentsEnrolled, err := u.courseRepository.HowManyEnrolled(ctx, int(course.ID))
+		output, err := u.newGetCourseOutput(ctx, course)
 		if err != nil {
 			return nil, err
 		}
 
-		courseOutput := &GetCourseOutput{
-			ID:              int(course.ID),
-			Name:            course.Name,
-			Description:     course.Description,
-			HowManyEnrolled: nStudentsEnrolled,
-		}
-
-		coursesOutput = append(coursesOutput, courseOutput)
+		coursesOutput = append(coursesOutput, output)
 	}
 
 	return coursesOutput, nil
@@ -145,6 +574,50 @@ func (e *errCourseFull) Error() string {
 	return fmt.Sprintf("course is full, max students: %d", e.MaxStudents)
 }
 
+// Is makes every errCourseFull match ErrCourseFull, whatever the capacity of
+// the course that returned it
+func (e *errCourseFull) Is(target error) bool {
+	_, ok := target.(*errCourseFull)
+	return ok
+}
+
+// ErrMissingPrerequisites is returned when a student tries to enroll in a
+// course without having completed all of its prerequisites
+type ErrMissingPrerequisites struct {
+	Missing []*model.Course
+}
+
+func (e *ErrMissingPrerequisites) Error() string {
+	names := make([]string, len(e.Missing))
+	for i, course := range e.Missing {
+		names[i] = course.Name
+	}
+
+	return fmt.Sprintf("student has not completed the prerequisites: %s", strings.Join(names, ", "))
+}
+
+// ErrScheduleConflict is returned when a slot of the course overlaps a slot of
+// another course the student is enrolled in
+type ErrScheduleConflict struct {
+	Course *model.Course
+	Slot   *model.CourseSlot
+}
+
+func (e *ErrScheduleConflict) Error() string {
+	return fmt.Sprintf("schedule conflicts with course %q on %s", e.Course.Name, e.Slot)
+}
+
+// ErrCapacityBelowEnrollment is returned when an offering capacity is lowered
+// below the number of students already enrolled in it
+type ErrCapacityBelowEnrollment struct {
+	Capacity int
+	Enrolled int
+}
+
+func (e *ErrCapacityBelowEnrollment) Error() string {
+	return fmt.Sprintf("capacity %d is lower than the %d students already enrolled", e.Capacity, e.Enrolled)
+}
+
 type errEnrolledTooManyCourses struct {
 	MaxCourses int
 }
@@ -153,45 +626,694 @@ func (e *errEnrolledTooManyCourses) Error() string {
 	return fmt.Sprintf("student is already enrolled in %d courses", e.MaxCourses)
 }
 
-var ErrCourseFull = &errCourseFull{MaxStudents: 10}
-var ErrEnrolledTooManyCourses = &errEnrolledTooManyCourses{MaxCourses: 3}
+// Is makes every errEnrolledTooManyCourses match ErrEnrolledTooManyCourses,
+// whatever the limit of the student that returned it
+func (e *errEnrolledTooManyCourses) Is(target error) bool {
+	_, ok := target.(*errEnrolledTooManyCourses)
+	return ok
+}
+
+var ErrCourseFull = &errCourseFull{}
+var ErrEnrolledTooManyCourses = &errEnrolledTooManyCourses{}
 var ErrStudentAlreadyEnrolled = repository.ErrStudentAlreadyEnrolled
+var ErrCourseNotFound = repository.ErrCourseNotFound
+var ErrStudentNotFound = repository.ErrStudentNotFound
+
+var ErrStudentAlreadyInWaitlist = repository.ErrStudentAlreadyInWaitlist
+var ErrStudentNotInWaitlist = repository.ErrStudentNotInWaitlist
+var ErrCourseNotFull = errors.New("course still has seats available")
+var ErrInvalidCapacity = errors.New("capacity must be greater than zero")
+var ErrEnrollmentWindowClosed = domain.ErrEnrollmentWindowClosed
+var ErrInvalidEnrollmentWindow = errors.New("enrollment_opens_at must be before enrollment_closes_at")
+var ErrPrerequisiteCycle = errors.New("prerequisite would create a cycle")
+var ErrPrerequisiteAlreadyAdded = repository.ErrPrerequisiteAlreadyAdded
+var ErrPrerequisiteNotFound = repository.ErrPrerequisiteNotFound
+var ErrSlotNotFound = repository.ErrSlotNotFound
+var ErrInvalidWeekday = errors.New("weekday must be between 0 (sunday) and 6 (saturday)")
+var ErrInvalidSlotTime = errors.New("start_time and end_time must be formatted as HH:MM and start_time must be before end_time")
+var ErrSlotOverlaps = errors.New("slot overlaps another slot of the course")
+var ErrEnrollmentNotFound = repository.ErrEnrollmentNotFound
+var ErrInvalidEnrollmentStatus = errors.New("status must be either completed or withdrawn")
+var ErrInvalidStatusTransition = errors.New("only active enrollments can be completed")
+var ErrReasonRequired = errors.New("a reason is required to withdraw an enrollment")
+var ErrInvalidPrice = errors.New("price must not be negative")
+var ErrInvalidCurrency = errors.New("currency must be a three letter ISO 4217 code, like BRL")
+var ErrCourseNotPublished = errors.New("course is not published yet")
+var ErrCourseArchived = errors.New("course is archived and no longer accepts enrollments")
+var ErrCourseAlreadyPublished = errors.New("course is already published")
+var ErrCourseNotArchivable = errors.New("only published courses can be archived")
+var ErrCourseNameRequired = errors.New("a course needs a name to be published")
+var ErrCourseDescriptionRequired = errors.New("a course needs a description to be published")
+var ErrInvalidCourseStatus = errors.New("status must be draft, published or archived")
+var ErrCourseNotDeleted = errors.New("course is not deleted")
+
+const (
+	EnrollmentResultEnrolled   = "enrolled"
+	EnrollmentResultWaitlisted = "waitlisted"
+)
 
-func (u *courseUsecase) EnrollStudent(ctx context.Context, courseID, studentID int) error {
-	nCourses, err := u.studentRepository.EnrolledInHowManyCourses(ctx, studentID)
+type EnrollStudentOutput struct {
+	Result           string `json:"result"`
+	WaitlistPosition int    `json:"waitlist_position,omitempty"`
+	// Invoice is the invoice to pay for the enrollment to become active, for
+	// paid courses
+	Invoice *model.Invoice `json:"invoice,omitempty"`
+}
+
+// EnrollStudent enrolls the student in the default offering of the course
+func (u *courseUsecase) EnrollStudent(ctx context.Context, courseID, studentID int, opts EnrollmentOptions) (*EnrollStudentOutput, error) {
+	offering, err := u.courseRepository.GetDefaultOffering(ctx, courseID)
 	if err != nil {
+		return nil, err
+	}
+
+	return u.EnrollStudentInOffering(ctx, int(offering.ID), studentID, opts)
+}
+
+// EnrollStudentInOffering runs the limit checks and the insert in a single
+// transaction. If the offering is full the student is put in its waitlist
+// instead. In paid courses the enrollment is pending and comes with the
+// invoice to pay.
+func (u *courseUsecase) EnrollStudentInOffering(ctx context.Context, offeringID, studentID int, opts EnrollmentOptions) (*EnrollStudentOutput, error) {
+	var output *EnrollStudentOutput
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.enroller.enroll(ctx, offeringID, studentID, opts, model.EnrollmentEventEnroll)
+		if errors.Is(err, ErrCourseFull) {
+			offering, err := u.courseRepository.GetOffering(ctx, offeringID)
+			if err != nil {
+				return err
+			}
+
+			err = u.waitlistRepository.AddStudentToWaitlist(ctx, offering, studentID)
+			if err != nil {
+				return err
+			}
+
+			position, err := u.waitlistRepository.WaitlistPosition(ctx, int(offering.ID), studentID)
+			if err != nil {
+				return err
+			}
+
+			output = &EnrollStudentOutput{Result: EnrollmentResultWaitlisted, WaitlistPosition: position}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		output = &EnrollStudentOutput{Result: EnrollmentResultEnrolled}
+
+		offering, err := u.courseRepository.GetOffering(ctx, offeringID)
+		if err != nil {
+			return err
+		}
+
+		enrollment, err := u.courseRepository.GetEnrollment(ctx, int(offering.CourseID), studentID)
+		if err != nil {
+			return err
+		}
+
+		if enrollment.Status != model.EnrollmentStatusPending {
+			return nil
+		}
+
+		output.Invoice, err = u.invoiceRepository.GetOpenInvoice(ctx, enrollment.ID)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if nCourses >= ErrEnrolledTooManyCourses.MaxCourses {
-		return ErrEnrolledTooManyCourses
+	// The enrollment is committed by now, so the charge of its invoice can be
+	// created without holding the course
+	if output.Invoice != nil {
+		output.Invoice = u.paymentTasks.runInvoice(ctx, output.Invoice)
 	}
 
-	nStudentsEnrolled, err := u.courseRepository.HowManyEnrolled(ctx, courseID)
+	return output, nil
+}
+
+// UnenrollStudent removes the student from the course and hands the freed seat
+// to the first eligible student in the waitlist of their offering
+func (u *courseUsecase) UnenrollStudent(ctx context.Context, courseID, studentID int, opts EnrollmentOptions) error {
+	return u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.courseRepository.LockCourse(ctx, courseID)
+		if err != nil {
+			return err
+		}
+
+		enrollment, err := u.courseRepository.GetEnrollment(ctx, courseID, studentID)
+		if err != nil {
+			return err
+		}
+
+		offering, err := u.courseRepository.GetOffering(ctx, int(enrollment.OfferingID))
+		if err != nil {
+			return err
+		}
+
+		err = checkEnrollmentWindow(offering, opts)
+		if err != nil {
+			return err
+		}
+
+		_, err = u.enroller.drop(ctx, courseID, studentID, model.EnrollmentEventUnenroll, nil)
+		if err != nil {
+			return err
+		}
+
+		return u.enroller.promoteFromWaitlist(ctx, int(offering.ID))
+	})
+}
+
+type CourseStudentOutput struct {
+	*model.Student
+	Attendance *model.AttendanceSummary `json:"attendance"`
+}
+
+// GetCourseStudents lists the students currently enrolled in the course along
+// with their attendance. When asOf is given the roster is rebuilt from the
+// enrollment history as it was at that time instead.
+func (u *courseUsecase) GetCourseStudents(ctx context.Context, courseID int, search string, asOf *time.Time) ([]*CourseStudentOutput, error) {
+	_, err := u.courseRepository.GetCourse(ctx, courseID)
 	if err != nil {
-		return err
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, err
 	}
 
-	if nStudentsEnrolled >= ErrCourseFull.MaxStudents {
-		return ErrCourseFull
+	var students []*model.Student
+	if asOf != nil {
+		students, err = u.courseRepository.GetCourseStudentsAsOf(ctx, courseID, *asOf, search)
+	} else {
+		students, err = u.courseRepository.GetCourseStudents(ctx, courseID, search)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	err = u.courseRepository.AddStudentToCourse(ctx, courseID, studentID)
+	return u.withAttendance(ctx, courseID, students)
+}
+
+// withAttendance pairs each student with their attendance in the course
+func (u *courseUsecase) withAttendance(ctx context.Context, courseID int, students []*model.Student) ([]*CourseStudentOutput, error) {
+	attendance, err := u.attendanceRepository.GetCourseAttendance(ctx, courseID)
 	if err != nil {
-		if err == repository.ErrStudentAlreadyEnrolled {
+		return nil, err
+	}
+
+	ids := make([]int64, len(students))
+	for i, student := range students {
+		ids[i] = student.ID
+	}
+	attendance = evaluateAttendance(attendance, ids, u.minAttendance)
+
+	output := make([]*CourseStudentOutput, len(students))
+	for i, student := range students {
+		output[i] = &CourseStudentOutput{Student: student, Attendance: attendance[student.ID]}
+	}
+
+	return output, nil
+}
+
+func (u *courseUsecase) GetWaitlist(ctx context.Context, courseID int) ([]*model.WaitlistEntry, error) {
+	_, err := u.courseRepository.GetCourse(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, err
+	}
+
+	entries, err := u.waitlistRepository.GetWaitlist(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if entries == nil {
+		return []*model.WaitlistEntry{}, nil
+	}
+
+	return entries, nil
+}
+
+// JoinWaitlist puts the student at the end of the waitlist of the default
+// offering of the course, which must be full, and returns their position
+func (u *courseUsecase) JoinWaitlist(ctx context.Context, courseID, studentID int) (int, error) {
+	var position int
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		defaultOffering, err := u.courseRepository.GetDefaultOffering(ctx, courseID)
+		if err != nil {
+			return err
+		}
+
+		offering, err := u.enroller.lockOffering(ctx, int(defaultOffering.ID))
+		if err != nil {
+			return err
+		}
+
+		course, err := u.courseRepository.GetCourse(ctx, courseID)
+		if err != nil {
+			return err
+		}
+
+		err = checkCourseOpen(course)
+		if err != nil {
+			return err
+		}
+
+		err = checkEnrollmentWindow(offering, EnrollmentOptions{})
+		if err != nil {
+			return err
+		}
+
+		err = u.studentRepository.LockStudent(ctx, studentID)
+		if err != nil {
+			return err
+		}
+
+		enrolled, err := u.courseRepository.IsStudentEnrolled(ctx, courseID, studentID)
+		if err != nil {
+			return err
+		}
+
+		if enrolled {
 			return ErrStudentAlreadyEnrolled
 		}
+
+		err = u.enroller.checkPrerequisites(ctx, courseID, studentID)
+		if err != nil {
+			return err
+		}
+
+		err = u.enroller.checkScheduleConflict(ctx, courseID, studentID)
+		if err != nil {
+			return err
+		}
+
+		seats, err := u.enroller.seatsAvailable(ctx, offering)
+		if err != nil {
+			return err
+		}
+
+		if seats > 0 {
+			return ErrCourseNotFull
+		}
+
+		err = u.waitlistRepository.AddStudentToWaitlist(ctx, offering, studentID)
+		if err != nil {
+			return err
+		}
+
+		position, err = u.waitlistRepository.WaitlistPosition(ctx, int(offering.ID), studentID)
 		return err
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return position, nil
+}
+
+func (u *courseUsecase) LeaveWaitlist(ctx context.Context, courseID, studentID int) error {
+	return u.waitlistRepository.RemoveStudentFromWaitlist(ctx, courseID, studentID)
 }
 
-func (u *courseUsecase) UnenrollStudent(ctx context.Context, courseID, studentID int) error {
-	err := u.courseRepository.RemoveStudentFromCourse(ctx, courseID, studentID)
+type ChangeEnrollmentStatusInput struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// ChangeEnrollmentStatus completes or withdraws the ongoing enrollment of a
+// student. Either way the seat is freed and handed to the waitlist.
+func (u *courseUsecase) ChangeEnrollmentStatus(ctx context.Context, courseID, studentID int, input ChangeEnrollmentStatusInput) (*model.Enrollment, error) {
+	switch input.Status {
+	case model.EnrollmentStatusCompleted:
+	case model.EnrollmentStatusWithdrawn:
+		if input.Reason == "" {
+			return nil, ErrReasonRequired
+		}
+	default:
+		return nil, ErrInvalidEnrollmentStatus
+	}
+
+	var enrollment *model.Enrollment
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.courseRepository.LockCourse(ctx, courseID)
+		if err != nil {
+			return err
+		}
+
+		enrollment, err = u.courseRepository.GetEnrollment(ctx, courseID, studentID)
+		if err != nil {
+			return err
+		}
+
+		if input.Status == model.EnrollmentStatusCompleted && enrollment.Status != model.EnrollmentStatusActive {
+			return ErrInvalidStatusTransition
+		}
+
+		err = u.enroller.releasePayment(ctx, enrollment)
+		if err != nil {
+			return err
+		}
+
+		enrollment.Status = input.Status
+		enrollment.Reason = nil
+		if input.Reason != "" {
+			enrollment.Reason = &input.Reason
+		}
+
+		err = u.courseRepository.UpdateEnrollmentStatus(ctx, enrollment)
+		if err != nil {
+			return err
+		}
+
+		err = u.enroller.recordEvent(ctx, enrollment, model.EnrollmentEventStatusChange, enrollment.Reason)
+		if err != nil {
+			return err
+		}
+
+		return u.enroller.promoteFromWaitlist(ctx, int(enrollment.OfferingID))
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return enrollment, nil
+}
+
+func (u *courseUsecase) GetPrerequisites(ctx context.Context, courseID int) ([]*model.Course, error) {
+	_, err := u.courseRepository.GetCourse(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, err
+	}
+
+	prerequisites, err := u.courseRepository.GetPrerequisites(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if prerequisites == nil {
+		return []*model.Course{}, nil
+	}
+
+	return prerequisites, nil
+}
+
+// AddPrerequisite makes prerequisiteID a prerequisite of courseID. It is
+// rejected when prerequisiteID already requires courseID, directly or not, as
+// no student would be able to enroll in either course.
+func (u *courseUsecase) AddPrerequisite(ctx context.Context, courseID, prerequisiteID int) error {
+	if courseID == prerequisiteID {
+		return ErrPrerequisiteCycle
+	}
+
+	return u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.courseRepository.LockPrerequisites(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range []int{courseID, prerequisiteID} {
+			_, err = u.courseRepository.GetCourse(ctx, id)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrCourseNotFound
+				}
+				return err
+			}
+		}
+
+		cycle, err := u.courseRepository.RequiresTransitively(ctx, prerequisiteID, courseID)
+		if err != nil {
+			return err
+		}
+
+		if cycle {
+			return ErrPrerequisiteCycle
+		}
+
+		return u.courseRepository.AddPrerequisite(ctx, courseID, prerequisiteID)
+	})
+}
+
+func (u *courseUsecase) RemovePrerequisite(ctx context.Context, courseID, prerequisiteID int) error {
+	return u.courseRepository.RemovePrerequisite(ctx, courseID, prerequisiteID)
+}
+
+type AddSlotInput struct {
+	Weekday   int    `json:"weekday"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
+func (u *courseUsecase) GetSlots(ctx context.Context, courseID int) ([]*model.CourseSlot, error) {
+	_, err := u.courseRepository.GetCourse(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, err
+	}
+
+	slots, err := u.courseRepository.GetSlots(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if slots == nil {
+		return []*model.CourseSlot{}, nil
+	}
+
+	return slots, nil
+}
+
+// AddSlot adds a weekly meeting time to the course. Students already enrolled
+// are not checked against the new slot.
+func (u *courseUsecase) AddSlot(ctx context.Context, courseID int, input AddSlotInput) (*model.CourseSlot, error) {
+	if input.Weekday < 0 || input.Weekday > 6 {
+		return nil, ErrInvalidWeekday
+	}
+
+	start, err := time.Parse("15:04", input.StartTime)
+	if err != nil {
+		return nil, ErrInvalidSlotTime
+	}
+
+	end, err := time.Parse("15:04", input.EndTime)
+	if err != nil {
+		return nil, ErrInvalidSlotTime
+	}
+
+	if !start.Before(end) {
+		return nil, ErrInvalidSlotTime
+	}
+
+	slot := &model.CourseSlot{
+		CourseID:  int64(courseID),
+		Weekday:   input.Weekday,
+		StartTime: start.Format("15:04"),
+		EndTime:   end.Format("15:04"),
+	}
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.courseRepository.LockCourse(ctx, courseID)
+		if err != nil {
+			return err
+		}
+
+		slots, err := u.courseRepository.GetSlots(ctx, courseID)
+		if err != nil {
+			return err
+		}
+
+		for _, other := range slots {
+			if slot.Overlaps(other) {
+				return ErrSlotOverlaps
+			}
+		}
+
+		return u.courseRepository.AddSlot(ctx, slot)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return slot, nil
+}
+
+func (u *courseUsecase) RemoveSlot(ctx context.Context, courseID, slotID int) error {
+	return u.courseRepository.RemoveSlot(ctx, courseID, slotID)
+}
+
+const (
+	BulkResultEnrolled             = "enrolled"
+	BulkResultAlreadyEnrolled      = "already_enrolled"
+	BulkResultCourseFull           = "course_full"
+	BulkResultTooManyCourses       = "too_many_courses"
+	BulkResultNotFound             = "not_found"
+	BulkResultMissingPrerequisites = "missing_prerequisites"
+	BulkResultScheduleConflict     = "schedule_conflict"
+	BulkResultEnrollmentClosed     = "enrollment_closed"
+	BulkResultCouponRejected       = "coupon_rejected"
+	// BulkResultRolledBack marks students that could be enrolled but weren't
+	// because another student failed in atomic mode
+	BulkResultRolledBack = "rolled_back"
+)
+
+var ErrNoStudents = errors.New("student_ids must not be empty")
+
+// errBulkRollback aborts the transaction of an atomic bulk enrollment
+var errBulkRollback = errors.New("bulk enrollment rolled back")
+
+type BulkEnrollInput struct {
+	StudentIDs []int `json:"student_ids"`
+	// Atomic rolls back every enrollment if any student can't be enrolled
+	Atomic bool `json:"-"`
+}
+
+type BulkEnrollResult struct {
+	StudentID int    `json:"student_id"`
+	Result    string `json:"result"`
+	Message   string `json:"message,omitempty"`
+}
+
+type BulkEnrollOutput struct {
+	Atomic    bool                `json:"atomic"`
+	Committed bool                `json:"committed"`
+	Results   []*BulkEnrollResult `json:"results"`
+}
+
+// bulkEnrollResult turns the outcome of enrolling a single student into a bulk
+// result. Errors that aren't about the student are returned as they are.
+func bulkEnrollResult(studentID int, err error) (*BulkEnrollResult, error) {
+	result := &BulkEnrollResult{StudentID: studentID}
+	if err != nil {
+		result.Message = err.Error()
+	}
+
+	var errMissing *ErrMissingPrerequisites
+	var errConflict *ErrScheduleConflict
+	switch {
+	case err == nil:
+		result.Result = BulkResultEnrolled
+	case err == ErrStudentAlreadyEnrolled:
+		result.Result = BulkResultAlreadyEnrolled
+	case errors.Is(err, ErrCourseFull):
+		result.Result = BulkResultCourseFull
+	case errors.Is(err, ErrEnrolledTooManyCourses):
+		result.Result = BulkResultTooManyCourses
+	case err == ErrStudentNotFound:
+		result.Result = BulkResultNotFound
+	case errors.As(err, &errMissing):
+		result.Result = BulkResultMissingPrerequisites
+	case errors.As(err, &errConflict):
+		result.Result = BulkResultScheduleConflict
+	case err == ErrEnrollmentWindowClosed:
+		result.Result = BulkResultEnrollmentClosed
+	case err == ErrCouponNotFound, err == ErrCouponNotValid, err == ErrCouponNotApplicable, err == ErrCouponExhausted:
+		result.Result = BulkResultCouponRejected
+	default:
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// BulkEnroll applies the EnrollStudent rules to each student, enrolling them in
+// the default offering of the course, except that a full offering doesn't put
+// students in the waitlist. Each student is enrolled in their
+// own transaction unless input.Atomic is set, in which case a single failure
+// rolls everything back.
+func (u *courseUsecase) BulkEnroll(ctx context.Context, courseID int, input BulkEnrollInput, opts EnrollmentOptions) (*BulkEnrollOutput, error) {
+	if len(input.StudentIDs) == 0 {
+		return nil, ErrNoStudents
+	}
+
+	offering, err := u.courseRepository.GetDefaultOffering(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	course, err := u.courseRepository.GetCourse(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkCourseOpen(course)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkEnrollmentWindow(offering, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	offeringID := int(offering.ID)
+	output := &BulkEnrollOutput{Atomic: input.Atomic}
+
+	if !input.Atomic {
+		for _, studentID := range input.StudentIDs {
+			err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				return u.enroller.enroll(ctx, offeringID, studentID, opts, model.EnrollmentEventEnroll)
+			})
+
+			result, err := bulkEnrollResult(studentID, err)
+			if err != nil {
+				return nil, err
+			}
+
+			output.Results = append(output.Results, result)
+		}
+
+		output.Committed = true
+		return output, nil
+	}
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		failed := false
+
+		for _, studentID := range input.StudentIDs {
+			result, err := bulkEnrollResult(studentID, u.enroller.enroll(ctx, offeringID, studentID, opts, model.EnrollmentEventEnroll))
+			if err != nil {
+				return err
+			}
+
+			if result.Result != BulkResultEnrolled {
+				failed = true
+			}
+
+			output.Results = append(output.Results, result)
+		}
+
+		if failed {
+			return errBulkRollback
+		}
+
+		return nil
+	})
+	if err == errBulkRollback {
+		for _, result := range output.Results {
+			if result.Result == BulkResultEnrolled {
+				result.Result = BulkResultRolledBack
+			}
+		}
+
+		return output, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	output.Committed = true
+	return output, nil
 }
@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/infrastructure/payment"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+// Deps carries the repositories and services shared by the usecases. Each
+// usecase takes what it needs from it, so they can all be built from the same
+// value.
+type Deps struct {
+	CourseRepository      repository.ICourseRepository
+	StudentRepository     repository.IStudentRepository
+	WaitlistRepository    repository.IWaitlistRepository
+	AttendanceRepository  repository.IAttendanceRepository
+	InstructorRepository  repository.IInstructorRepository
+	RoomRepository        repository.IRoomRepository
+	CategoryRepository    repository.ICategoryRepository
+	InvoiceRepository     repository.IInvoiceRepository
+	CouponRepository      repository.ICouponRepository
+	ScholarshipRepository repository.IScholarshipRepository
+	Transactor            repository.ITransactor
+	Gateway               payment.IPaymentGateway
+}
+
+// Config holds the settings of the enrollment rules
+type Config struct {
+	// InvoiceTTL is how long an invoice can go unpaid before it expires and
+	// the seat it holds is released
+	InvoiceTTL time.Duration
+	// DefaultMaxCourses is the enrollment limit of the students without one
+	// of their own
+	DefaultMaxCourses int
+	// MinAttendance is the attendance percentage below which a student is
+	// flagged as at risk
+	MinAttendance float64
+}
@@ -0,0 +1,606 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/core/domain"
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/payment"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+// enroller holds the enrollment rules shared by the course and student
+// usecases. Its methods must be called inside a transaction.
+type enroller struct {
+	courseRepository      repository.ICourseRepository
+	studentRepository     repository.IStudentRepository
+	waitlistRepository    repository.IWaitlistRepository
+	invoiceRepository     repository.IInvoiceRepository
+	couponRepository      repository.ICouponRepository
+	scholarshipRepository repository.IScholarshipRepository
+	gateway               payment.IPaymentGateway
+	// invoiceTTL is how long a student has to pay for a paid course before
+	// their seat is released
+	invoiceTTL time.Duration
+	// defaultMaxCourses applies to students without an override
+	defaultMaxCourses int
+}
+
+func newEnroller(deps Deps, config Config) *enroller {
+	return &enroller{
+		courseRepository:      deps.CourseRepository,
+		studentRepository:     deps.StudentRepository,
+		waitlistRepository:    deps.WaitlistRepository,
+		invoiceRepository:     deps.InvoiceRepository,
+		couponRepository:      deps.CouponRepository,
+		scholarshipRepository: deps.ScholarshipRepository,
+		gateway:               deps.Gateway,
+		invoiceTTL:            config.InvoiceTTL,
+		defaultMaxCourses:     config.DefaultMaxCourses,
+	}
+}
+
+// EnrollmentOptions carries the optional parts of an enrollment request
+type EnrollmentOptions struct {
+	// IgnoreEnrollmentWindow lets the request through outside the course
+	// enrollment window, for administrative requests
+	IgnoreEnrollmentWindow bool
+	// CouponCode is a discount coupon to apply to the price of the course.
+	// It is not kept for students put in the waitlist.
+	CouponCode string
+}
+
+// lockOffering locks the course of the offering, the same lock taken by every
+// enrollment change in the course, and returns the offering as it is once the
+// lock is held
+func (e *enroller) lockOffering(ctx context.Context, offeringID int) (*model.CourseOffering, error) {
+	offering, err := e.courseRepository.GetOffering(ctx, offeringID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.courseRepository.LockCourse(ctx, int(offering.CourseID))
+	if err != nil {
+		return nil, err
+	}
+
+	return e.courseRepository.GetOffering(ctx, offeringID)
+}
+
+// enroll locks the course row first and the student row second, so concurrent
+// enrollments in the same course or of the same student are serialized and the
+// limits can't be exceeded. event is the kind of change recorded in the
+// enrollment history.
+func (e *enroller) enroll(ctx context.Context, offeringID, studentID int, opts EnrollmentOptions, event string) error {
+	offering, err := e.lockOffering(ctx, offeringID)
+	if err != nil {
+		return err
+	}
+
+	courseID := int(offering.CourseID)
+
+	course, err := e.courseRepository.GetCourse(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCourseNotFound
+		}
+		return err
+	}
+
+	err = checkCourseOpen(course)
+	if err != nil {
+		return err
+	}
+
+	err = checkEnrollmentWindow(offering, opts)
+	if err != nil {
+		return err
+	}
+
+	err = e.studentRepository.LockStudent(ctx, studentID)
+	if err != nil {
+		return err
+	}
+
+	enrolled, err := e.courseRepository.IsStudentEnrolled(ctx, courseID, studentID)
+	if err != nil {
+		return err
+	}
+
+	if enrolled {
+		return ErrStudentAlreadyEnrolled
+	}
+
+	err = e.checkPrerequisites(ctx, courseID, studentID)
+	if err != nil {
+		return err
+	}
+
+	err = e.checkScheduleConflict(ctx, courseID, studentID)
+	if err != nil {
+		return err
+	}
+
+	err = e.checkStudentLimit(ctx, studentID)
+	if err != nil {
+		return err
+	}
+
+	var coupon *model.Coupon
+	if opts.CouponCode != "" {
+		coupon, err = e.checkCoupon(ctx, opts.CouponCode, course)
+		if err != nil {
+			return err
+		}
+	}
+
+	seats, err := e.seatsAvailable(ctx, offering)
+	if err != nil {
+		return err
+	}
+
+	if seats <= 0 {
+		return &errCourseFull{MaxStudents: offering.Capacity}
+	}
+
+	return e.addStudentToOffering(ctx, offering, studentID, event, nil, coupon)
+}
+
+// checkCoupon returns the coupon with the given code if it can be used to
+// enroll in the course now. Its redemption limit is checked again, atomically,
+// when it is redeemed.
+func (e *enroller) checkCoupon(ctx context.Context, code string, course *model.Course) (*model.Coupon, error) {
+	coupon, err := e.couponRepository.GetCouponByCode(ctx, normalizeCouponCode(code))
+	if err != nil {
+		return nil, err
+	}
+
+	if !coupon.Valid(time.Now()) {
+		return nil, ErrCouponNotValid
+	}
+
+	if course.Price == 0 {
+		return nil, ErrCouponNotApplicable
+	}
+
+	if len(coupon.CourseIDs) > 0 && !slices.Contains(coupon.CourseIDs, course.ID) {
+		return nil, ErrCouponNotApplicable
+	}
+
+	if coupon.DiscountType == model.DiscountTypeFixed && *coupon.Currency != course.Currency {
+		return nil, ErrCouponNotApplicable
+	}
+
+	if coupon.MaxRedemptions != nil && coupon.Redemptions >= *coupon.MaxRedemptions {
+		return nil, ErrCouponExhausted
+	}
+
+	return coupon, nil
+}
+
+// normalizeCouponCode uppercases the code, codes are compared case
+// insensitively
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// enrollmentPrice is what a student pays for a course once their scholarship
+// and coupon are taken off its price
+type enrollmentPrice struct {
+	Amount         int64
+	Discount       int64
+	CouponDiscount int64
+	// Coupon and Scholarship are only set when they took something off
+	Coupon      *model.Coupon
+	Scholarship *model.Scholarship
+}
+
+// price applies the best scholarship of the student first and the coupon, if
+// any, to what is left
+func (e *enroller) price(ctx context.Context, course *model.Course, studentID int, coupon *model.Coupon) (*enrollmentPrice, error) {
+	price := &enrollmentPrice{Amount: course.Price}
+
+	if course.Price == 0 {
+		return price, nil
+	}
+
+	scholarship, err := e.scholarshipRepository.GetBestScholarship(ctx, studentID, int(course.ID), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if scholarship != nil {
+		discount := scholarship.Discount(price.Amount)
+		if discount > 0 {
+			price.Scholarship = scholarship
+			price.Amount -= discount
+			price.Discount += discount
+		}
+	}
+
+	if coupon != nil {
+		discount := coupon.Discount(price.Amount)
+		if discount > 0 {
+			price.Coupon = coupon
+			price.CouponDiscount = discount
+			price.Amount -= discount
+			price.Discount += discount
+		}
+	}
+
+	return price, nil
+}
+
+// checkCourseOpen rejects enrollments in courses that are not published, drafts
+// not yet and archived courses no longer
+func checkCourseOpen(course *model.Course) error {
+	switch course.Status {
+	case model.CourseStatusDraft:
+		return ErrCourseNotPublished
+	case model.CourseStatusArchived:
+		return ErrCourseArchived
+	}
+
+	return nil
+}
+
+func checkEnrollmentWindow(offering *model.CourseOffering, opts EnrollmentOptions) error {
+	if opts.IgnoreEnrollmentWindow || offering.EnrollmentOpen(time.Now()) {
+		return nil
+	}
+
+	return ErrEnrollmentWindowClosed
+}
+
+// seatsAvailable returns how many students can still enroll in the offering
+func (e *enroller) seatsAvailable(ctx context.Context, offering *model.CourseOffering) (int, error) {
+	nStudentsEnrolled, err := e.courseRepository.HowManyEnrolled(ctx, int(offering.ID))
+	if err != nil {
+		return 0, err
+	}
+
+	return offering.Capacity - nStudentsEnrolled, nil
+}
+
+// maxCourses returns the enrollment limit of the student, which is their own
+// override when they have one and the configured default otherwise
+func (e *enroller) maxCourses(ctx context.Context, studentID int) (int, error) {
+	student, err := e.studentRepository.GetStudent(ctx, studentID)
+	if err != nil {
+		return 0, err
+	}
+
+	if student.MaxCourses != nil {
+		return *student.MaxCourses, nil
+	}
+
+	return e.defaultMaxCourses, nil
+}
+
+func (e *enroller) checkStudentLimit(ctx context.Context, studentID int) error {
+	maxCourses, err := e.maxCourses(ctx, studentID)
+	if err != nil {
+		return err
+	}
+
+	nCourses, err := e.studentRepository.EnrolledInHowManyCourses(ctx, studentID)
+	if err != nil {
+		return err
+	}
+
+	if nCourses >= maxCourses {
+		return &errEnrolledTooManyCourses{MaxCourses: maxCourses}
+	}
+
+	return nil
+}
+
+func (e *enroller) checkPrerequisites(ctx context.Context, courseID, studentID int) error {
+	missing, err := e.courseRepository.GetMissingPrerequisites(ctx, courseID, studentID)
+	if err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		return &ErrMissingPrerequisites{Missing: missing}
+	}
+
+	return nil
+}
+
+// checkScheduleConflict rejects the enrollment when a meeting slot of the
+// course overlaps a slot of a course the student is already taking
+func (e *enroller) checkScheduleConflict(ctx context.Context, courseID, studentID int) error {
+	slot, err := e.courseRepository.FindScheduleConflict(ctx, courseID, studentID)
+	if err != nil {
+		return err
+	}
+
+	if slot == nil {
+		return nil
+	}
+
+	course, err := e.courseRepository.GetCourse(ctx, int(slot.CourseID))
+	if err != nil {
+		return err
+	}
+
+	return &ErrScheduleConflict{Course: course, Slot: slot}
+}
+
+// addStudentToOffering inserts the enrollment, records it in the history and
+// drops the student from the course waitlist, if they were in it. Enrollments
+// with something left to pay once discounts are applied stay pending, holding
+// the seat, until their invoice is paid. The coupon is optional.
+func (e *enroller) addStudentToOffering(ctx context.Context, offering *model.CourseOffering, studentID int, event string, reason *string, coupon *model.Coupon) error {
+	courseID := int(offering.CourseID)
+
+	course, err := e.courseRepository.GetCourse(ctx, courseID)
+	if err != nil {
+		return err
+	}
+
+	price, err := e.price(ctx, course, studentID, coupon)
+	if err != nil {
+		return err
+	}
+
+	if price.Coupon != nil {
+		err = e.couponRepository.RedeemCoupon(ctx, price.Coupon.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	status := model.EnrollmentStatusActive
+	if price.Amount > 0 {
+		status = model.EnrollmentStatusPending
+	}
+
+	err = e.courseRepository.AddStudentToOffering(ctx, offering, studentID, status)
+	if err != nil {
+		if err == repository.ErrStudentAlreadyEnrolled {
+			return ErrStudentAlreadyEnrolled
+		}
+		return err
+	}
+
+	enrollment, err := e.courseRepository.GetEnrollment(ctx, courseID, studentID)
+	if err != nil {
+		return err
+	}
+
+	err = e.recordEvent(ctx, enrollment, event, reason)
+	if err != nil {
+		return err
+	}
+
+	if price.Coupon != nil {
+		err = e.couponRepository.SaveRedemption(ctx, price.Coupon.ID, enrollment.ID, price.CouponDiscount)
+		if err != nil {
+			return err
+		}
+	}
+
+	if status == model.EnrollmentStatusPending {
+		err = e.createInvoice(ctx, course, enrollment, price)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = e.waitlistRepository.RemoveStudentFromWaitlist(ctx, courseID, studentID)
+	if err != nil && err != repository.ErrStudentNotInWaitlist {
+		return err
+	}
+
+	return nil
+}
+
+// createInvoice bills the student for the course. The charge is opened in the
+// payment gateway once the transaction commits.
+func (e *enroller) createInvoice(ctx context.Context, course *model.Course, enrollment *model.Enrollment, price *enrollmentPrice) error {
+	invoice := &model.Invoice{
+		EnrollmentID: enrollment.ID,
+		StudentID:    enrollment.StudentID,
+		CourseID:     course.ID,
+		Amount:       price.Amount,
+		Discount:     price.Discount,
+		Currency:     course.Currency,
+		Gateway:      e.gateway.Name(),
+		ExpiresAt:    time.Now().Add(e.invoiceTTL),
+	}
+
+	if price.Coupon != nil {
+		invoice.CouponID = &price.Coupon.ID
+	}
+
+	if price.Scholarship != nil {
+		invoice.ScholarshipID = &price.Scholarship.ID
+	}
+
+	err := e.invoiceRepository.SaveInvoice(ctx, invoice)
+	if err != nil {
+		return err
+	}
+
+	return e.queuePaymentTask(ctx, invoice, model.PaymentTaskCreateCharge)
+}
+
+// queuePaymentTask records a call to the payment gateway to be made for the
+// invoice once the transaction commits
+func (e *enroller) queuePaymentTask(ctx context.Context, invoice *model.Invoice, kind string) error {
+	return e.invoiceRepository.SavePaymentTask(ctx, &model.PaymentTask{InvoiceID: invoice.ID, Kind: kind})
+}
+
+// releasePayment undoes the payment side of a pending enrollment that is
+// leaving the course: the coupon it redeemed can be used again and its open
+// invoice, if any, is canceled so it can no longer be paid. The charge is
+// canceled in the gateway once the transaction commits.
+func (e *enroller) releasePayment(ctx context.Context, enrollment *model.Enrollment) error {
+	if enrollment.Status != model.EnrollmentStatusPending {
+		return nil
+	}
+
+	err := e.couponRepository.ReleaseRedemption(ctx, enrollment.ID)
+	if err != nil {
+		return err
+	}
+
+	invoice, err := e.invoiceRepository.GetOpenInvoice(ctx, enrollment.ID)
+	if err == repository.ErrInvoiceNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	invoice.Status = model.InvoiceStatusCanceled
+
+	err = e.invoiceRepository.UpdateInvoiceStatus(ctx, invoice)
+	if err != nil {
+		return err
+	}
+
+	return e.queuePaymentTask(ctx, invoice, model.PaymentTaskCancelCharge)
+}
+
+// drop marks the ongoing enrollment of the student in the course as dropped,
+// freeing their seat, and returns it. The caller is responsible for promoting
+// from the waitlist of its offering.
+func (e *enroller) drop(ctx context.Context, courseID, studentID int, event string, reason *string) (*model.Enrollment, error) {
+	enrollment, err := e.courseRepository.GetEnrollment(ctx, courseID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.releasePayment(ctx, enrollment)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment.Status = model.EnrollmentStatusDropped
+	enrollment.Reason = reason
+
+	err = e.courseRepository.UpdateEnrollmentStatus(ctx, enrollment)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.recordEvent(ctx, enrollment, event, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+// recordEvent appends the current state of the enrollment to its history,
+// attributed to the actor of the request
+func (e *enroller) recordEvent(ctx context.Context, enrollment *model.Enrollment, event string, reason *string) error {
+	return e.courseRepository.AddEnrollmentEvent(ctx, &model.EnrollmentEvent{
+		EnrollmentID: enrollment.ID,
+		CourseID:     enrollment.CourseID,
+		StudentID:    enrollment.StudentID,
+		Event:        event,
+		Status:       enrollment.Status,
+		Reason:       reason,
+		Actor:        domain.ActorFromContext(ctx),
+	})
+}
+
+// promoteFromWaitlist fills the free seats of an offering with the students
+// waiting for it, in FIFO order. Students who reached their course limit, miss
+// a prerequisite or have a schedule conflict keep their place in the waitlist
+// and are skipped. Nobody is promoted while the course is not published. The
+// course must already be locked. Promotions are recorded as done by the
+// system, not by whoever freed the seat.
+func (e *enroller) promoteFromWaitlist(ctx context.Context, offeringID int) error {
+	ctx = domain.WithActor(ctx, domain.SystemActor)
+
+	offering, err := e.courseRepository.GetOffering(ctx, offeringID)
+	if err != nil {
+		return err
+	}
+
+	courseID := int(offering.CourseID)
+
+	// Nobody is promoted into deleted courses
+	course, err := e.courseRepository.GetCourse(ctx, courseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if checkCourseOpen(course) != nil {
+		return nil
+	}
+
+	entries, err := e.waitlistRepository.GetWaitlist(ctx, courseID)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.OfferingID != offering.ID {
+			continue
+		}
+
+		seats, err := e.seatsAvailable(ctx, offering)
+		if err != nil {
+			return err
+		}
+
+		if seats <= 0 {
+			return nil
+		}
+
+		studentID := int(entry.StudentID)
+
+		err = e.studentRepository.LockStudent(ctx, studentID)
+		if err != nil {
+			return err
+		}
+
+		err = e.checkStudentLimit(ctx, studentID)
+		if errors.Is(err, ErrEnrolledTooManyCourses) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = e.checkPrerequisites(ctx, courseID, studentID)
+		var errMissing *ErrMissingPrerequisites
+		if errors.As(err, &errMissing) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = e.checkScheduleConflict(ctx, courseID, studentID)
+		var errConflict *ErrScheduleConflict
+		if errors.As(err, &errConflict) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		reason := "promoted from the waitlist"
+		err = e.addStudentToOffering(ctx, offering, studentID, model.EnrollmentEventEnroll, &reason, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+
+	"github.com/felipedavid/vrcursos/src/core/domain"
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type SetGradeInput struct {
+	Value    float64 `json:"value"`
+	Finalize bool    `json:"finalize"`
+	// CorrectionReason is required to change a finalized grade
+	CorrectionReason string `json:"correction_reason"`
+}
+
+type GetTranscriptOutput struct {
+	Student *model.Student           `json:"student"`
+	Courses []*model.TranscriptEntry `json:"courses"`
+	// Average is the mean of the finalized grades, nil when none was
+	// finalized. Draft grades are listed but left out of it.
+	Average *float64 `json:"average"`
+}
+
+var ErrGradeNotFound = repository.ErrGradeNotFound
+var ErrInvalidGrade = errors.New("grade must be between 0 and 10")
+var ErrEnrollmentNotGradable = errors.New("only active or completed enrollments can be graded")
+var ErrCorrectionReasonRequired = errors.New("correction_reason is required to change a finalized grade")
+
+type GradeUsecase interface {
+	SetGrade(ctx context.Context, courseID, studentID int, input SetGradeInput) (*model.Grade, error)
+	GetGrade(ctx context.Context, courseID, studentID int) (*model.Grade, error)
+	GetTranscript(ctx context.Context, studentID int) (*GetTranscriptOutput, error)
+}
+
+type gradeUsecase struct {
+	gradeRepository   repository.IGradeRepository
+	courseRepository  repository.ICourseRepository
+	studentRepository repository.IStudentRepository
+	transactor        repository.ITransactor
+}
+
+func NewGradeUsecase(gradeRepo repository.IGradeRepository, courseRepo repository.ICourseRepository, studentRepo repository.IStudentRepository, transactor repository.ITransactor) GradeUsecase {
+	return &gradeUsecase{
+		gradeRepository:   gradeRepo,
+		courseRepository:  courseRepo,
+		studentRepository: studentRepo,
+		transactor:        transactor,
+	}
+}
+
+// SetGrade grades the latest enrollment of the student in the course. Once a
+// grade is finalized it stays finalized, and every later change is recorded as
+// a correction along with its reason.
+func (u *gradeUsecase) SetGrade(ctx context.Context, courseID, studentID int, input SetGradeInput) (*model.Grade, error) {
+	value := roundGrade(input.Value)
+	if value < model.MinGrade || value > model.MaxGrade {
+		return nil, ErrInvalidGrade
+	}
+
+	var grade *model.Grade
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.studentRepository.LockStudent(ctx, studentID)
+		if err != nil {
+			return err
+		}
+
+		enrollment, err := u.courseRepository.GetLastEnrollment(ctx, courseID, studentID)
+		if err != nil {
+			return err
+		}
+
+		if enrollment.Status != model.EnrollmentStatusActive && enrollment.Status != model.EnrollmentStatusCompleted {
+			return ErrEnrollmentNotGradable
+		}
+
+		grade, err = u.gradeRepository.GetGrade(ctx, enrollment.ID)
+		if err == ErrGradeNotFound {
+			grade = &model.Grade{
+				EnrollmentID: enrollment.ID,
+				Value:        value,
+				Finalized:    input.Finalize,
+			}
+			return u.gradeRepository.SaveGrade(ctx, grade)
+		}
+		if err != nil {
+			return err
+		}
+
+		if grade.Finalized && grade.Value != value {
+			if input.CorrectionReason == "" {
+				return ErrCorrectionReasonRequired
+			}
+
+			err = u.gradeRepository.AddGradeCorrection(ctx, &model.GradeCorrection{
+				GradeID:  grade.ID,
+				OldValue: grade.Value,
+				NewValue: value,
+				Reason:   input.CorrectionReason,
+				Actor:    domain.ActorFromContext(ctx),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		grade.Value = value
+		grade.Finalized = grade.Finalized || input.Finalize
+
+		return u.gradeRepository.UpdateGrade(ctx, grade)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	grade.Concept = model.GradeConcept(grade.Value)
+
+	return grade, nil
+}
+
+// roundGrade rounds the grade to the two decimals it is stored with, so a
+// grade is compared to the stored one as it would be stored
+func roundGrade(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func (u *gradeUsecase) GetGrade(ctx context.Context, courseID, studentID int) (*model.Grade, error) {
+	enrollment, err := u.courseRepository.GetLastEnrollment(ctx, courseID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	grade, err := u.gradeRepository.GetGrade(ctx, enrollment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	grade.Concept = model.GradeConcept(grade.Value)
+
+	return grade, nil
+}
+
+// GetTranscript lists the completed courses of the student with their grades.
+// Only finalized grades count toward the average, courses not graded yet or
+// graded with a draft are listed but left out of it.
+func (u *gradeUsecase) GetTranscript(ctx context.Context, studentID int) (*GetTranscriptOutput, error) {
+	student, err := u.studentRepository.GetStudent(ctx, studentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrStudentNotFound
+		}
+		return nil, err
+	}
+
+	entries, err := u.gradeRepository.GetTranscript(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &GetTranscriptOutput{
+		Student: student,
+		Courses: entries,
+	}
+
+	var sum float64
+	var finalized int
+
+	for _, entry := range entries {
+		if entry.Grade == nil {
+			continue
+		}
+
+		entry.Concept = model.GradeConcept(*entry.Grade)
+		if !entry.Finalized {
+			continue
+		}
+
+		sum += *entry.Grade
+		finalized++
+	}
+
+	if finalized > 0 {
+		average := sum / float64(finalized)
+		output.Average = &average
+	}
+
+	return output, nil
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type CreateInstructorInput struct {
+	Name string `json:"name"`
+}
+
+type UpdateInstructorInput struct {
+	Name string `json:"name"`
+}
+
+type AssignInstructorInput struct {
+	Role string `json:"role"`
+}
+
+var ErrInstructorNotFound = repository.ErrInstructorNotFound
+var ErrInstructorNotAssigned = repository.ErrInstructorNotAssigned
+var ErrInvalidInstructorRole = errors.New("role must be one of 'lead' or 'assistant'")
+
+type InstructorUsecase interface {
+	CreateInstructor(ctx context.Context, input CreateInstructorInput) (*model.Instructor, error)
+	GetInstructor(ctx context.Context, id int) (*model.Instructor, error)
+	GetInstructors(ctx context.Context, search string) ([]*model.Instructor, error)
+	UpdateInstructor(ctx context.Context, id int, input UpdateInstructorInput) (*model.Instructor, error)
+	DeleteInstructor(ctx context.Context, id int) error
+	AssignInstructor(ctx context.Context, courseID, instructorID int, input AssignInstructorInput) error
+	UnassignInstructor(ctx context.Context, courseID, instructorID int) error
+	GetInstructorCourses(ctx context.Context, id int) ([]*model.InstructorCourse, error)
+}
+
+type instructorUsecase struct {
+	instructorRepository repository.IInstructorRepository
+	courseRepository     repository.ICourseRepository
+}
+
+func NewInstructorUsecase(repo repository.IInstructorRepository, courseRepo repository.ICourseRepository) InstructorUsecase {
+	return &instructorUsecase{
+		instructorRepository: repo,
+		courseRepository:     courseRepo,
+	}
+}
+
+func (u *instructorUsecase) CreateInstructor(ctx context.Context, input CreateInstructorInput) (*model.Instructor, error) {
+	instructor := &model.Instructor{
+		Name: input.Name,
+	}
+
+	err := u.instructorRepository.Save(ctx, instructor)
+	if err != nil {
+		return nil, err
+	}
+
+	return instructor, nil
+}
+
+func (u *instructorUsecase) GetInstructor(ctx context.Context, id int) (*model.Instructor, error) {
+	instructor, err := u.instructorRepository.GetInstructor(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInstructorNotFound
+		}
+		return nil, err
+	}
+
+	return instructor, nil
+}
+
+func (u *instructorUsecase) GetInstructors(ctx context.Context, search string) ([]*model.Instructor, error) {
+	instructors, err := u.instructorRepository.GetInstructors(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	if instructors == nil {
+		return []*model.Instructor{}, nil
+	}
+
+	return instructors, nil
+}
+
+func (u *instructorUsecase) UpdateInstructor(ctx context.Context, id int, input UpdateInstructorInput) (*model.Instructor, error) {
+	instructor, err := u.GetInstructor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	instructor.Name = input.Name
+
+	err = u.instructorRepository.UpdateInstructor(ctx, instructor)
+	if err != nil {
+		return nil, err
+	}
+
+	return instructor, nil
+}
+
+func (u *instructorUsecase) DeleteInstructor(ctx context.Context, id int) error {
+	return u.instructorRepository.DeleteInstructor(ctx, id)
+}
+
+// AssignInstructor assigns the instructor to the course with the given role.
+// Assigning an instructor again only changes their role.
+func (u *instructorUsecase) AssignInstructor(ctx context.Context, courseID, instructorID int, input AssignInstructorInput) error {
+	if input.Role != model.InstructorRoleLead && input.Role != model.InstructorRoleAssistant {
+		return ErrInvalidInstructorRole
+	}
+
+	_, err := u.courseRepository.GetCourse(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCourseNotFound
+		}
+		return err
+	}
+
+	_, err = u.GetInstructor(ctx, instructorID)
+	if err != nil {
+		return err
+	}
+
+	return u.instructorRepository.AssignCourse(ctx, courseID, instructorID, input.Role)
+}
+
+func (u *instructorUsecase) UnassignInstructor(ctx context.Context, courseID, instructorID int) error {
+	return u.instructorRepository.UnassignCourse(ctx, courseID, instructorID)
+}
+
+// GetInstructorCourses lists the courses the instructor is assigned to along
+// with their role in each of them
+func (u *instructorUsecase) GetInstructorCourses(ctx context.Context, id int) ([]*model.InstructorCourse, error) {
+	_, err := u.GetInstructor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	courses, err := u.instructorRepository.GetInstructorCourses(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if courses == nil {
+		return []*model.InstructorCourse{}, nil
+	}
+
+	return courses, nil
+}
@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+// DefaultOfferingName names the offering created along with a course
+const DefaultOfferingName = "Default"
+
+type CreateOfferingInput struct {
+	Name     string     `json:"name"`
+	StartsOn *time.Time `json:"starts_on"`
+	EndsOn   *time.Time `json:"ends_on"`
+	Capacity int        `json:"capacity"`
+	// A nil bound leaves that side of the enrollment window open
+	EnrollmentOpensAt  *time.Time `json:"enrollment_opens_at"`
+	EnrollmentClosesAt *time.Time `json:"enrollment_closes_at"`
+}
+
+type UpdateOfferingInput struct {
+	Name     string     `json:"name"`
+	StartsOn *time.Time `json:"starts_on"`
+	EndsOn   *time.Time `json:"ends_on"`
+	// Capacity and the bounds of the enrollment window are left untouched when
+	// omitted
+	Capacity           *int       `json:"capacity"`
+	EnrollmentOpensAt  *time.Time `json:"enrollment_opens_at"`
+	EnrollmentClosesAt *time.Time `json:"enrollment_closes_at"`
+}
+
+type GetOfferingOutput struct {
+	*model.CourseOffering
+	HowManyEnrolled int `json:"how_many_enrolled"`
+	SeatsAvailable  int `json:"seats_available"`
+}
+
+var ErrOfferingNotFound = repository.ErrOfferingNotFound
+var ErrOfferingNameRequired = errors.New("name is required")
+var ErrInvalidOfferingDates = errors.New("starts_on must not be after ends_on")
+
+func validateOffering(name string, startsOn, endsOn, opensAt, closesAt *time.Time) error {
+	if name == "" {
+		return ErrOfferingNameRequired
+	}
+
+	if startsOn != nil && endsOn != nil && startsOn.After(*endsOn) {
+		return ErrInvalidOfferingDates
+	}
+
+	return validateEnrollmentWindow(opensAt, closesAt)
+}
+
+// CreateOffering adds an offering to the course. Its capacity must fit in the
+// rooms booked for the course.
+func (u *courseUsecase) CreateOffering(ctx context.Context, courseID int, input CreateOfferingInput) (*model.CourseOffering, error) {
+	if input.Capacity == 0 {
+		input.Capacity = DefaultCourseCapacity
+	}
+
+	if input.Capacity < 0 {
+		return nil, ErrInvalidCapacity
+	}
+
+	err := validateOffering(input.Name, input.StartsOn, input.EndsOn, input.EnrollmentOpensAt, input.EnrollmentClosesAt)
+	if err != nil {
+		return nil, err
+	}
+
+	offering := &model.CourseOffering{
+		CourseID:           int64(courseID),
+		Name:               input.Name,
+		StartsOn:           input.StartsOn,
+		EndsOn:             input.EndsOn,
+		Capacity:           input.Capacity,
+		EnrollmentOpensAt:  input.EnrollmentOpensAt,
+		EnrollmentClosesAt: input.EnrollmentClosesAt,
+	}
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.courseRepository.LockCourse(ctx, courseID)
+		if err != nil {
+			return err
+		}
+
+		err = checkRoomSeats(ctx, u.roomRepository, courseID, offering.Capacity)
+		if err != nil {
+			return err
+		}
+
+		return u.courseRepository.SaveOffering(ctx, offering)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return offering, nil
+}
+
+func (u *courseUsecase) GetOfferings(ctx context.Context, courseID int) ([]*GetOfferingOutput, error) {
+	_, err := u.courseRepository.GetCourse(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, err
+	}
+
+	return u.getOfferings(ctx, courseID)
+}
+
+// getOfferings returns the offerings of the course with their seats taken
+func (u *courseUsecase) getOfferings(ctx context.Context, courseID int) ([]*GetOfferingOutput, error) {
+	offerings, err := u.courseRepository.GetOfferings(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := []*GetOfferingOutput{}
+
+	// TODO: Refactor this in only a single query
+	for _, offering := range offerings {
+		offeringOutput, err := u.newGetOfferingOutput(ctx, offering)
+		if err != nil {
+			return nil, err
+		}
+
+		output = append(output, offeringOutput)
+	}
+
+	return output, nil
+}
+
+func (u *courseUsecase) newGetOfferingOutput(ctx context.Context, offering *model.CourseOffering) (*GetOfferingOutput, error) {
+	nStudentsEnrolled, err := u.courseRepository.HowManyEnrolled(ctx, int(offering.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetOfferingOutput{
+		CourseOffering:  offering,
+		HowManyEnrolled: nStudentsEnrolled,
+		SeatsAvailable:  max(offering.Capacity-nStudentsEnrolled, 0),
+	}, nil
+}
+
+func (u *courseUsecase) GetOffering(ctx context.Context, id int) (*GetOfferingOutput, error) {
+	offering, err := u.courseRepository.GetOffering(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.newGetOfferingOutput(ctx, offering)
+}
+
+// UpdateOffering rejects lowering the capacity below the number of students
+// already enrolled, or raising it above the seats of a room booked for the
+// course. Raising it promotes students from the waitlist.
+func (u *courseUsecase) UpdateOffering(ctx context.Context, id int, input UpdateOfferingInput) (*model.CourseOffering, error) {
+	// The enrollment window is validated once merged with the current one
+	err := validateOffering(input.Name, input.StartsOn, input.EndsOn, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var offering *model.CourseOffering
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		offering, err = u.enroller.lockOffering(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		offering.Name = input.Name
+		offering.StartsOn = input.StartsOn
+		offering.EndsOn = input.EndsOn
+
+		if input.EnrollmentOpensAt != nil {
+			offering.EnrollmentOpensAt = input.EnrollmentOpensAt
+		}
+
+		if input.EnrollmentClosesAt != nil {
+			offering.EnrollmentClosesAt = input.EnrollmentClosesAt
+		}
+
+		err = validateEnrollmentWindow(offering.EnrollmentOpensAt, offering.EnrollmentClosesAt)
+		if err != nil {
+			return err
+		}
+
+		if input.Capacity != nil {
+			if *input.Capacity <= 0 {
+				return ErrInvalidCapacity
+			}
+
+			nStudentsEnrolled, err := u.courseRepository.HowManyEnrolled(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if *input.Capacity < nStudentsEnrolled {
+				return &ErrCapacityBelowEnrollment{Capacity: *input.Capacity, Enrolled: nStudentsEnrolled}
+			}
+
+			err = checkRoomSeats(ctx, u.roomRepository, int(offering.CourseID), *input.Capacity)
+			if err != nil {
+				return err
+			}
+
+			offering.Capacity = *input.Capacity
+		}
+
+		err = u.courseRepository.UpdateOffering(ctx, offering)
+		if err != nil {
+			return err
+		}
+
+		return u.enroller.promoteFromWaitlist(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return offering, nil
+}
+
+// GetOfferingStudents lists the students currently enrolled in the offering
+// along with their attendance in the course
+func (u *courseUsecase) GetOfferingStudents(ctx context.Context, id int, search string) ([]*CourseStudentOutput, error) {
+	offering, err := u.courseRepository.GetOffering(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := u.courseRepository.GetOfferingStudents(ctx, id, search)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.withAttendance(ctx, int(offering.CourseID), students)
+}
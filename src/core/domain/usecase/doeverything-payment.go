@@ -0,0 +1,382 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/payment"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+var ErrInvoiceNotFound = repository.ErrInvoiceNotFound
+var ErrInvalidNotification = payment.ErrInvalidNotification
+
+type PaymentUsecase interface {
+	ConfirmPayment(ctx context.Context, notification *payment.Notification) (*model.Invoice, error)
+	ExpireInvoices(ctx context.Context, now time.Time) (int, error)
+	ProcessPaymentTasks(ctx context.Context) (int, error)
+	GetInvoice(ctx context.Context, id int) (*model.Invoice, error)
+	GetStudentInvoices(ctx context.Context, studentID int) ([]*model.Invoice, error)
+}
+
+type paymentUsecase struct {
+	invoiceRepository repository.IInvoiceRepository
+	courseRepository  repository.ICourseRepository
+	studentRepository repository.IStudentRepository
+	transactor        repository.ITransactor
+	gateway           payment.IPaymentGateway
+	enroller          *enroller
+	paymentTasks      *paymentTasks
+}
+
+func NewPaymentUsecase(deps Deps, config Config) PaymentUsecase {
+	return &paymentUsecase{
+		invoiceRepository: deps.InvoiceRepository,
+		courseRepository:  deps.CourseRepository,
+		studentRepository: deps.StudentRepository,
+		transactor:        deps.Transactor,
+		gateway:           deps.Gateway,
+		enroller:          newEnroller(deps, config),
+		paymentTasks:      newPaymentTasks(deps),
+	}
+}
+
+// lockInvoice locks the course of the invoice, the same lock taken by every
+// enrollment change in the course, then the invoice itself, and returns the
+// invoice as it is once the locks are held
+func (u *paymentUsecase) lockInvoice(ctx context.Context, invoice *model.Invoice) (*model.Invoice, error) {
+	err := u.courseRepository.LockCourse(ctx, int(invoice.CourseID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.invoiceRepository.LockInvoice(ctx, invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.invoiceRepository.GetInvoice(ctx, int(invoice.ID))
+}
+
+// ConfirmPayment applies a notification from the payment gateway. A paid
+// invoice activates its pending enrollment. An invoice paid after it expired
+// or was canceled has no seat to activate anymore, the payment is recorded as
+// late and refunded. Gateways may deliver the same notification more than
+// once, so confirming an invoice already paid is a no-op.
+func (u *paymentUsecase) ConfirmPayment(ctx context.Context, notification *payment.Notification) (*model.Invoice, error) {
+	invoice, err := u.invoiceRepository.GetInvoiceByReference(ctx, u.gateway.Name(), notification.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		invoice, err = u.lockInvoice(ctx, invoice)
+		if err != nil {
+			return err
+		}
+
+		if !notification.Paid {
+			return nil
+		}
+
+		switch invoice.Status {
+		case model.InvoiceStatusOpen:
+			return u.activateInvoice(ctx, invoice)
+		case model.InvoiceStatusExpired, model.InvoiceStatusCanceled:
+			invoice.Status = model.InvoiceStatusPaidLate
+
+			err = u.invoiceRepository.UpdateInvoiceStatus(ctx, invoice)
+			if err != nil {
+				return err
+			}
+
+			return u.enroller.queuePaymentTask(ctx, invoice, model.PaymentTaskRefundCharge)
+		default:
+			return nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if invoice.Status == model.InvoiceStatusPaidLate {
+		invoice = u.paymentTasks.runInvoice(ctx, invoice)
+	}
+
+	return invoice, nil
+}
+
+// activateInvoice marks the open invoice as paid and activates its pending
+// enrollment
+func (u *paymentUsecase) activateInvoice(ctx context.Context, invoice *model.Invoice) error {
+	invoice.Status = model.InvoiceStatusPaid
+
+	err := u.invoiceRepository.UpdateInvoiceStatus(ctx, invoice)
+	if err != nil {
+		return err
+	}
+
+	enrollment, err := u.courseRepository.GetEnrollmentByID(ctx, invoice.EnrollmentID)
+	if err != nil {
+		return err
+	}
+
+	if enrollment.Status != model.EnrollmentStatusPending {
+		return nil
+	}
+
+	reason := "invoice paid"
+	enrollment.Status = model.EnrollmentStatusActive
+	enrollment.Reason = &reason
+
+	err = u.courseRepository.UpdateEnrollmentStatus(ctx, enrollment)
+	if err != nil {
+		return err
+	}
+
+	return u.enroller.recordEvent(ctx, enrollment, model.EnrollmentEventStatusChange, &reason)
+}
+
+// ExpireInvoices expires the open invoices that were due before now, dropping
+// their pending enrollments and handing the freed seats to the waitlist. Each
+// invoice is expired in its own transaction, and one paid in the meantime is
+// left alone. It returns how many invoices were expired.
+func (u *paymentUsecase) ExpireInvoices(ctx context.Context, now time.Time) (int, error) {
+	invoices, err := u.invoiceRepository.GetExpiredInvoices(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+
+	for _, invoice := range invoices {
+		var open bool
+
+		err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			invoice, err := u.lockInvoice(ctx, invoice)
+			if err != nil {
+				return err
+			}
+
+			open = invoice.Status == model.InvoiceStatusOpen
+			if !open {
+				return nil
+			}
+
+			invoice.Status = model.InvoiceStatusExpired
+
+			err = u.invoiceRepository.UpdateInvoiceStatus(ctx, invoice)
+			if err != nil {
+				return err
+			}
+
+			err = u.enroller.queuePaymentTask(ctx, invoice, model.PaymentTaskCancelCharge)
+			if err != nil {
+				return err
+			}
+
+			enrollment, err := u.courseRepository.GetEnrollmentByID(ctx, invoice.EnrollmentID)
+			if err != nil {
+				return err
+			}
+
+			if enrollment.Status != model.EnrollmentStatusPending {
+				return nil
+			}
+
+			reason := "invoice expired"
+			_, err = u.enroller.drop(ctx, int(enrollment.CourseID), int(enrollment.StudentID), model.EnrollmentEventUnenroll, &reason)
+			if err != nil {
+				return err
+			}
+
+			return u.enroller.promoteFromWaitlist(ctx, int(enrollment.OfferingID))
+		})
+		if err != nil {
+			return expired, err
+		}
+
+		if open {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// ProcessPaymentTasks makes the calls to the payment gateway recorded by the
+// enrollment changes. It returns how many were made.
+func (u *paymentUsecase) ProcessPaymentTasks(ctx context.Context) (int, error) {
+	tasks, err := u.invoiceRepository.GetPendingPaymentTasks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return u.paymentTasks.run(ctx, tasks)
+}
+
+func (u *paymentUsecase) GetInvoice(ctx context.Context, id int) (*model.Invoice, error) {
+	return u.invoiceRepository.GetInvoice(ctx, id)
+}
+
+func (u *paymentUsecase) GetStudentInvoices(ctx context.Context, studentID int) ([]*model.Invoice, error) {
+	_, err := u.studentRepository.GetStudent(ctx, studentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrStudentNotFound
+		}
+		return nil, err
+	}
+
+	invoices, err := u.invoiceRepository.GetStudentInvoices(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if invoices == nil {
+		return []*model.Invoice{}, nil
+	}
+
+	return invoices, nil
+}
+
+// paymentTasks runs the calls to the payment gateway recorded in the
+// transactions of the enrollment changes. Unlike the enroller it must be used
+// out of any transaction, each task runs in its own so no other lock is held
+// while the gateway answers.
+type paymentTasks struct {
+	invoiceRepository repository.IInvoiceRepository
+	transactor        repository.ITransactor
+	gateway           payment.IPaymentGateway
+}
+
+func newPaymentTasks(deps Deps) *paymentTasks {
+	return &paymentTasks{
+		invoiceRepository: deps.InvoiceRepository,
+		transactor:        deps.Transactor,
+		gateway:           deps.Gateway,
+	}
+}
+
+// run runs the tasks in order. A task the gateway fails stays pending to be
+// tried again later, the failures are returned together once every task had
+// its turn. It returns how many tasks were processed.
+func (p *paymentTasks) run(ctx context.Context, tasks []*model.PaymentTask) (int, error) {
+	processed := 0
+	var failures []error
+
+	for _, task := range tasks {
+		var done bool
+		var failure error
+
+		err := p.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			task, err := p.invoiceRepository.ClaimPaymentTask(ctx, task.ID)
+			if err == repository.ErrPaymentTaskNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			failure = p.call(ctx, task)
+			if failure != nil {
+				return p.invoiceRepository.FailPaymentTask(ctx, task, failure.Error())
+			}
+
+			done = true
+			return p.invoiceRepository.FinishPaymentTask(ctx, task)
+		})
+		if err != nil {
+			return processed, err
+		}
+
+		if failure != nil {
+			failures = append(failures, fmt.Errorf("payment task %d: %w", task.ID, failure))
+		}
+
+		if done {
+			processed++
+		}
+	}
+
+	return processed, errors.Join(failures...)
+}
+
+// runInvoice runs the pending tasks of the invoice right away, like the charge
+// of an invoice just created, and returns the invoice as they left it. When
+// the gateway fails the invoice is returned as it was, the tasks are retried
+// by the background processing.
+func (p *paymentTasks) runInvoice(ctx context.Context, invoice *model.Invoice) *model.Invoice {
+	tasks, err := p.invoiceRepository.GetInvoicePendingPaymentTasks(ctx, invoice.ID)
+	if err != nil {
+		return invoice
+	}
+
+	_, err = p.run(ctx, tasks)
+	if err != nil {
+		return invoice
+	}
+
+	charged, err := p.invoiceRepository.GetInvoice(ctx, int(invoice.ID))
+	if err != nil {
+		return invoice
+	}
+
+	return charged
+}
+
+// paymentTaskKey is the idempotency key of the gateway call made by the task.
+// A task whose result couldn't be stored is run again, and the gateway must
+// recognize the retry.
+func paymentTaskKey(task *model.PaymentTask) string {
+	return fmt.Sprintf("payment-task-%d", task.ID)
+}
+
+// call makes the gateway call the task stands for
+func (p *paymentTasks) call(ctx context.Context, task *model.PaymentTask) error {
+	invoice, err := p.invoiceRepository.GetInvoice(ctx, int(task.InvoiceID))
+	if err != nil {
+		return err
+	}
+
+	switch task.Kind {
+	case model.PaymentTaskCreateCharge:
+		// An invoice closed before its charge was created has nothing to
+		// charge anymore
+		if invoice.Status != model.InvoiceStatusOpen {
+			return nil
+		}
+
+		charge, err := p.gateway.CreateCharge(ctx, invoice, paymentTaskKey(task))
+		if err != nil {
+			return err
+		}
+
+		invoice.Reference = &charge.Reference
+		invoice.PaymentURL = &charge.PaymentURL
+
+		return p.invoiceRepository.SetInvoiceCharge(ctx, invoice)
+	case model.PaymentTaskCancelCharge:
+		// The charge was never created
+		if invoice.Reference == nil {
+			return nil
+		}
+
+		return p.gateway.CancelCharge(ctx, invoice)
+	case model.PaymentTaskRefundCharge:
+		err = p.gateway.RefundCharge(ctx, invoice, paymentTaskKey(task))
+		if err != nil {
+			return err
+		}
+
+		invoice.Status = model.InvoiceStatusRefunded
+
+		return p.invoiceRepository.UpdateInvoiceStatus(ctx, invoice)
+	default:
+		return fmt.Errorf("unknown payment task kind %q", task.Kind)
+	}
+}
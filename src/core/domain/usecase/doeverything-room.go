@@ -0,0 +1,312 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type CreateRoomInput struct {
+	Name  string `json:"name"`
+	Seats int    `json:"seats"`
+}
+
+type UpdateRoomInput struct {
+	Name  string `json:"name"`
+	Seats int    `json:"seats"`
+}
+
+type BookRoomInput struct {
+	RoomID int `json:"room_id"`
+}
+
+type RoomTimetableOutput struct {
+	*model.Room
+	Days []*RoomTimetableDay `json:"days"`
+}
+
+// RoomTimetableDay lists the bookings of a room on a weekday. OccupiedMinutes
+// adds up the length of every booking.
+type RoomTimetableDay struct {
+	Weekday         int                  `json:"weekday"`
+	Name            string               `json:"name"`
+	Bookings        []*model.RoomBooking `json:"bookings"`
+	OccupiedMinutes int                  `json:"occupied_minutes"`
+}
+
+var ErrRoomNotFound = repository.ErrRoomNotFound
+var ErrRoomNameRequired = errors.New("name is required")
+var ErrInvalidSeats = errors.New("seats must be greater than zero")
+
+// ErrRoomTooSmall is returned when a course can't fit in a room it is or would
+// be booked in
+type ErrRoomTooSmall struct {
+	Room     *model.Room
+	Capacity int
+}
+
+func (e *ErrRoomTooSmall) Error() string {
+	return fmt.Sprintf("room %q has %d seats, fewer than the course capacity of %d", e.Room.Name, e.Room.Seats, e.Capacity)
+}
+
+// ErrRoomBooked is returned when a room is already used by another slot at an
+// overlapping time
+type ErrRoomBooked struct {
+	Booking *model.RoomBooking
+}
+
+func (e *ErrRoomBooked) Error() string {
+	return fmt.Sprintf("room is already booked by course %q on %s", e.Booking.CourseName, e.Booking.CourseSlot)
+}
+
+type RoomUsecase interface {
+	CreateRoom(ctx context.Context, input CreateRoomInput) (*model.Room, error)
+	GetRoom(ctx context.Context, id int) (*model.Room, error)
+	GetRooms(ctx context.Context) ([]*model.Room, error)
+	UpdateRoom(ctx context.Context, id int, input UpdateRoomInput) (*model.Room, error)
+	DeleteRoom(ctx context.Context, id int) error
+	GetTimetable(ctx context.Context, id int) (*RoomTimetableOutput, error)
+	BookRoom(ctx context.Context, courseID, slotID int, input BookRoomInput) (*model.CourseSlot, error)
+	ReleaseRoom(ctx context.Context, courseID, slotID int) error
+}
+
+type roomUsecase struct {
+	roomRepository   repository.IRoomRepository
+	courseRepository repository.ICourseRepository
+	transactor       repository.ITransactor
+}
+
+func NewRoomUsecase(repo repository.IRoomRepository, courseRepo repository.ICourseRepository, transactor repository.ITransactor) RoomUsecase {
+	return &roomUsecase{
+		roomRepository:   repo,
+		courseRepository: courseRepo,
+		transactor:       transactor,
+	}
+}
+
+func validateRoom(name string, seats int) error {
+	if strings.TrimSpace(name) == "" {
+		return ErrRoomNameRequired
+	}
+
+	if seats <= 0 {
+		return ErrInvalidSeats
+	}
+
+	return nil
+}
+
+func (u *roomUsecase) CreateRoom(ctx context.Context, input CreateRoomInput) (*model.Room, error) {
+	err := validateRoom(input.Name, input.Seats)
+	if err != nil {
+		return nil, err
+	}
+
+	room := &model.Room{
+		Name:  input.Name,
+		Seats: input.Seats,
+	}
+
+	err = u.roomRepository.Save(ctx, room)
+	if err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func (u *roomUsecase) GetRoom(ctx context.Context, id int) (*model.Room, error) {
+	return u.roomRepository.GetRoom(ctx, id)
+}
+
+func (u *roomUsecase) GetRooms(ctx context.Context) ([]*model.Room, error) {
+	rooms, err := u.roomRepository.GetRooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if rooms == nil {
+		return []*model.Room{}, nil
+	}
+
+	return rooms, nil
+}
+
+// UpdateRoom rejects lowering the seats below the capacity of a course booked
+// in the room
+func (u *roomUsecase) UpdateRoom(ctx context.Context, id int, input UpdateRoomInput) (*model.Room, error) {
+	err := validateRoom(input.Name, input.Seats)
+	if err != nil {
+		return nil, err
+	}
+
+	var room *model.Room
+
+	err = u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := u.roomRepository.LockRoom(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		room, err = u.roomRepository.GetRoom(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		room.Name = input.Name
+		room.Seats = input.Seats
+
+		capacity, err := u.roomRepository.MaxBookedCapacity(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if capacity > room.Seats {
+			return &ErrRoomTooSmall{Room: room, Capacity: capacity}
+		}
+
+		return u.roomRepository.UpdateRoom(ctx, room)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func (u *roomUsecase) DeleteRoom(ctx context.Context, id int) error {
+	return u.roomRepository.DeleteRoom(ctx, id)
+}
+
+// GetTimetable returns the weekly occupancy of the room, from sunday to
+// saturday
+func (u *roomUsecase) GetTimetable(ctx context.Context, id int) (*RoomTimetableOutput, error) {
+	room, err := u.roomRepository.GetRoom(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	bookings, err := u.roomRepository.GetRoomBookings(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]*RoomTimetableDay, 7)
+	for weekday := range days {
+		days[weekday] = &RoomTimetableDay{
+			Weekday:  weekday,
+			Name:     time.Weekday(weekday).String(),
+			Bookings: []*model.RoomBooking{},
+		}
+	}
+
+	for _, booking := range bookings {
+		day := days[booking.Weekday]
+		day.Bookings = append(day.Bookings, booking)
+
+		start, err := time.Parse("15:04", booking.StartTime)
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := time.Parse("15:04", booking.EndTime)
+		if err != nil {
+			return nil, err
+		}
+
+		day.OccupiedMinutes += int(end.Sub(start).Minutes())
+	}
+
+	return &RoomTimetableOutput{Room: room, Days: days}, nil
+}
+
+// BookRoom books the room for a slot of the course. The room must seat every
+// offering of the course and be free during the slot.
+func (u *roomUsecase) BookRoom(ctx context.Context, courseID, slotID int, input BookRoomInput) (*model.CourseSlot, error) {
+	var slot *model.CourseSlot
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Same order used when changing the capacity of an offering: the
+		// course first, then its rooms
+		err := u.courseRepository.LockCourse(ctx, courseID)
+		if err != nil {
+			return err
+		}
+
+		slot, err = u.courseRepository.GetSlot(ctx, courseID, slotID)
+		if err != nil {
+			return err
+		}
+
+		err = u.roomRepository.LockRoom(ctx, input.RoomID)
+		if err != nil {
+			return err
+		}
+
+		room, err := u.roomRepository.GetRoom(ctx, input.RoomID)
+		if err != nil {
+			return err
+		}
+
+		offerings, err := u.courseRepository.GetOfferings(ctx, courseID)
+		if err != nil {
+			return err
+		}
+
+		for _, offering := range offerings {
+			if offering.Capacity > room.Seats {
+				return &ErrRoomTooSmall{Room: room, Capacity: offering.Capacity}
+			}
+		}
+
+		bookings, err := u.roomRepository.GetRoomBookings(ctx, input.RoomID)
+		if err != nil {
+			return err
+		}
+
+		for _, booking := range bookings {
+			if booking.ID != slot.ID && slot.Overlaps(booking.CourseSlot) {
+				return &ErrRoomBooked{Booking: booking}
+			}
+		}
+
+		err = u.courseRepository.SetSlotRoom(ctx, courseID, slotID, &input.RoomID)
+		if err != nil {
+			return err
+		}
+
+		slot.RoomID = &room.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return slot, nil
+}
+
+func (u *roomUsecase) ReleaseRoom(ctx context.Context, courseID, slotID int) error {
+	return u.courseRepository.SetSlotRoom(ctx, courseID, slotID, nil)
+}
+
+// checkRoomSeats makes sure the rooms booked for the course seat the given
+// capacity
+func checkRoomSeats(ctx context.Context, roomRepo repository.IRoomRepository, courseID, capacity int) error {
+	rooms, err := roomRepo.GetCourseRooms(ctx, courseID)
+	if err != nil {
+		return err
+	}
+
+	for _, room := range rooms {
+		if capacity > room.Seats {
+			return &ErrRoomTooSmall{Room: room, Capacity: capacity}
+		}
+	}
+
+	return nil
+}
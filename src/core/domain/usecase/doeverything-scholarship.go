@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type CreateScholarshipInput struct {
+	// CourseID limits the scholarship to a course, it applies to every course
+	// when omitted
+	CourseID   *int64     `json:"course_id"`
+	Percent    int        `json:"percent"`
+	Reason     string     `json:"reason"`
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+var ErrScholarshipNotFound = repository.ErrScholarshipNotFound
+var ErrInvalidScholarshipPercent = errors.New("percent must be between 1 and 100")
+var ErrScholarshipReasonRequired = errors.New("reason is required")
+
+type ScholarshipUsecase interface {
+	CreateScholarship(ctx context.Context, studentID int, input CreateScholarshipInput) (*model.Scholarship, error)
+	GetStudentScholarships(ctx context.Context, studentID int) ([]*model.Scholarship, error)
+	DeleteScholarship(ctx context.Context, studentID, id int) error
+}
+
+type scholarshipUsecase struct {
+	scholarshipRepository repository.IScholarshipRepository
+	studentRepository     repository.IStudentRepository
+	courseRepository      repository.ICourseRepository
+}
+
+func NewScholarshipUsecase(deps Deps) ScholarshipUsecase {
+	return &scholarshipUsecase{
+		scholarshipRepository: deps.ScholarshipRepository,
+		studentRepository:     deps.StudentRepository,
+		courseRepository:      deps.CourseRepository,
+	}
+}
+
+// CreateScholarship grants a scholarship to the student. It only applies to
+// enrollments made from then on, while it is valid.
+func (u *scholarshipUsecase) CreateScholarship(ctx context.Context, studentID int, input CreateScholarshipInput) (*model.Scholarship, error) {
+	if input.Percent < 1 || input.Percent > 100 {
+		return nil, ErrInvalidScholarshipPercent
+	}
+
+	if strings.TrimSpace(input.Reason) == "" {
+		return nil, ErrScholarshipReasonRequired
+	}
+
+	err := validateValidity(input.ValidFrom, input.ValidUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.checkStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.CourseID != nil {
+		_, err := u.courseRepository.GetCourse(ctx, int(*input.CourseID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrCourseNotFound
+			}
+			return nil, err
+		}
+	}
+
+	scholarship := &model.Scholarship{
+		StudentID:  int64(studentID),
+		CourseID:   input.CourseID,
+		Percent:    input.Percent,
+		Reason:     input.Reason,
+		ValidFrom:  input.ValidFrom,
+		ValidUntil: input.ValidUntil,
+	}
+
+	err = u.scholarshipRepository.SaveScholarship(ctx, scholarship)
+	if err != nil {
+		return nil, err
+	}
+
+	return scholarship, nil
+}
+
+func (u *scholarshipUsecase) checkStudent(ctx context.Context, studentID int) error {
+	_, err := u.studentRepository.GetStudent(ctx, studentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrStudentNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (u *scholarshipUsecase) GetStudentScholarships(ctx context.Context, studentID int) ([]*model.Scholarship, error) {
+	err := u.checkStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	scholarships, err := u.scholarshipRepository.GetStudentScholarships(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if scholarships == nil {
+		return []*model.Scholarship{}, nil
+	}
+
+	return scholarships, nil
+}
+
+func (u *scholarshipUsecase) DeleteScholarship(ctx context.Context, studentID, id int) error {
+	return u.scholarshipRepository.DeleteScholarship(ctx, studentID, id)
+}
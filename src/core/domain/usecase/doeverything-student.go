@@ -2,6 +2,13 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/felipedavid/vrcursos/src/core/domain"
 	"github.com/felipedavid/vrcursos/src/core/model"
@@ -9,37 +16,223 @@ import (
 )
 
 type CreateStudentInput struct {
-	Name string `json:"name"`
+	Name      string     `json:"name"`
+	Email     *string    `json:"email"`
+	Phone     *string    `json:"phone"`
+	BirthDate *time.Time `json:"birth_date"`
+	// CPF may come formatted, like 123.456.789-09
+	CPF *string `json:"cpf"`
 }
 
+// UpdateStudentInput replaces the profile of the student, fields left out are
+// cleared
 type UpdateStudentInput struct {
-	Name string `json:"name"`
+	Name      string     `json:"name"`
+	Email     *string    `json:"email"`
+	Phone     *string    `json:"phone"`
+	BirthDate *time.Time `json:"birth_date"`
+	CPF       *string    `json:"cpf"`
+}
+
+type GetStudentOutput struct {
+	*model.Student
+	// Courses is only filled when requested. It is a pointer so a student
+	// without courses still gets an empty list instead of no key at all.
+	Courses *[]*StudentCourseOutput `json:"courses,omitempty"`
+}
+
+type StudentCourseOutput struct {
+	*model.Course
+	Attendance *model.AttendanceSummary `json:"attendance"`
+}
+
+type SetEnrollmentLimitInput struct {
+	MaxCourses int `json:"max_courses"`
+}
+
+type TransferStudentInput struct {
+	FromCourseID int `json:"from_course_id"`
+	ToCourseID   int `json:"to_course_id"`
+	// ToOfferingID picks an offering of the new course, its default offering
+	// is used when omitted
+	ToOfferingID *int `json:"to_offering_id"`
+}
+
+var ErrInvalidEnrollmentLimit = errors.New("max_courses must not be negative")
+var ErrTransferSameCourse = errors.New("from_course_id and to_course_id must be different")
+var ErrStudentNotDeleted = errors.New("student is not deleted")
+var ErrStudentEmailTaken = repository.ErrStudentEmailTaken
+var ErrStudentCPFTaken = repository.ErrStudentCPFTaken
+var ErrInvalidEmail = errors.New("email is not a valid email address")
+var ErrInvalidPhone = errors.New("phone must have between 10 and 13 digits, like +55 11 91234-5678")
+var ErrInvalidBirthDate = errors.New("birth_date must be in the past")
+var ErrInvalidCPF = errors.New("cpf is not valid")
+
+// ErrStudentHasEnrollments is returned when deleting a student who is still
+// enrolled in courses without asking to unenroll them
+type ErrStudentHasEnrollments struct {
+	Enrollments int
+}
+
+func (e *ErrStudentHasEnrollments) Error() string {
+	return fmt.Sprintf("student still has %d active enrollments, delete them with cascade=true to unenroll them", e.Enrollments)
 }
 
 type StudentUsecase interface {
 	CreateStudent(ctx context.Context, input CreateStudentInput) (*model.Student, error)
-	GetStudent(ctx context.Context, id int) (*model.Student, error)
-	GetStudents(ctx context.Context, search string) ([]*model.Student, error)
+	GetStudent(ctx context.Context, id int, includeCourses, includeDeleted bool) (*GetStudentOutput, error)
+	GetStudents(ctx context.Context, search string, includeDeleted bool) ([]*model.Student, error)
 	UpdateStudent(ctx context.Context, id int, input UpdateStudentInput) (*model.Student, error)
-	DeleteStudent(ctx context.Context, id int) error
+	DeleteStudent(ctx context.Context, id int, cascade bool) error
+	RestoreStudent(ctx context.Context, id int) (*model.Student, error)
+	PurgeStudents(ctx context.Context, deletedBefore time.Time) (int, error)
+	SetEnrollmentLimit(ctx context.Context, id int, input SetEnrollmentLimitInput) error
+	ClearEnrollmentLimit(ctx context.Context, id int) error
+	GetStudentCourses(ctx context.Context, id int, search string) ([]*StudentCourseOutput, error)
+	TransferStudent(ctx context.Context, id int, input TransferStudentInput, opts EnrollmentOptions) (*model.Enrollment, error)
 }
 
 type studentUsecase struct {
-	studentRepository repository.IStudentRepository
+	studentRepository    repository.IStudentRepository
+	courseRepository     repository.ICourseRepository
+	attendanceRepository repository.IAttendanceRepository
+	transactor           repository.ITransactor
+	enroller             *enroller
+	// minAttendance is the attendance percentage below which students are
+	// flagged as at risk
+	minAttendance float64
 }
 
-func NewStudentUsecase(repo repository.IStudentRepository) StudentUsecase {
+func NewStudentUsecase(deps Deps, config Config) StudentUsecase {
 	return &studentUsecase{
-		studentRepository: repo,
+		studentRepository:    deps.StudentRepository,
+		courseRepository:     deps.CourseRepository,
+		attendanceRepository: deps.AttendanceRepository,
+		transactor:           deps.Transactor,
+		enroller:             newEnroller(deps, config),
+		minAttendance:        config.MinAttendance,
 	}
 }
 
+// setProfile validates the profile of the student and stores it normalized
+// the way it is kept in the database
+func setProfile(student *model.Student, email, phone *string, birthDate *time.Time, cpf *string) error {
+	student.Email = nil
+	if email != nil {
+		normalized := strings.ToLower(strings.TrimSpace(*email))
+		address, err := mail.ParseAddress(normalized)
+		if err != nil || address.Address != normalized {
+			return ErrInvalidEmail
+		}
+		student.Email = &normalized
+	}
+
+	student.Phone = nil
+	if phone != nil {
+		normalized, ok := normalizePhone(*phone)
+		if !ok {
+			return ErrInvalidPhone
+		}
+		student.Phone = &normalized
+	}
+
+	if birthDate != nil && !birthDate.Before(time.Now()) {
+		return ErrInvalidBirthDate
+	}
+	student.BirthDate = birthDate
+
+	student.CPF = nil
+	if cpf != nil {
+		normalized, ok := normalizeCPF(*cpf)
+		if !ok || !validCPF(normalized) {
+			return ErrInvalidCPF
+		}
+		student.CPF = &normalized
+	}
+
+	return nil
+}
+
+// normalizePhone keeps only the digits of the phone, which must be a brazilian
+// number with the area code and optionally the country code
+func normalizePhone(phone string) (string, bool) {
+	digits := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return r
+		case r == '+' || r == '(' || r == ')' || r == '-' || r == '.' || r == ' ':
+			return -1
+		default:
+			return 'x'
+		}
+	}, phone)
+
+	if strings.Contains(digits, "x") || len(digits) < 10 || len(digits) > 13 {
+		return "", false
+	}
+
+	return digits, true
+}
+
+// normalizeCPF strips the punctuation of a CPF, returning its 11 digits
+func normalizeCPF(cpf string) (string, bool) {
+	digits := strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, cpf)
+
+	if len(digits) != 11 {
+		return "", false
+	}
+
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+
+	return digits, true
+}
+
+// validCPF checks the two check digits of a normalized CPF. CPFs made of a
+// single repeated digit pass the check but are not valid.
+func validCPF(cpf string) bool {
+	if strings.Count(cpf, cpf[:1]) == len(cpf) {
+		return false
+	}
+
+	for n := 9; n <= 10; n++ {
+		sum := 0
+		for i := 0; i < n; i++ {
+			sum += int(cpf[i]-'0') * (n + 1 - i)
+		}
+
+		digit := sum * 10 % 11
+		if digit == 10 {
+			digit = 0
+		}
+
+		if digit != int(cpf[n]-'0') {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (u *studentUsecase) CreateStudent(ctx context.Context, input CreateStudentInput) (*model.Student, error) {
 	student := &model.Student{
 		Name: input.Name,
 	}
 
-	err := u.studentRepository.Save(ctx, student)
+	err := setProfile(student, input.Email, input.Phone, input.BirthDate, input.CPF)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.studentRepository.Save(ctx, student)
 	if err != nil {
 		return nil, err
 	}
@@ -47,23 +240,53 @@ func (u *studentUsecase) CreateStudent(ctx context.Context, input CreateStudentI
 	return student, nil
 }
 
-func (u *studentUsecase) GetStudent(ctx context.Context, id int) (*model.Student, error) {
-	student, err := u.studentRepository.GetStudent(ctx, id)
+// GetStudent returns the student, deleted students are only found when
+// includeDeleted is set
+func (u *studentUsecase) GetStudent(ctx context.Context, id int, includeCourses, includeDeleted bool) (*GetStudentOutput, error) {
+	var student *model.Student
+	var err error
+
+	if includeDeleted {
+		student, err = u.studentRepository.GetStudentIncludingDeleted(ctx, id)
+	} else {
+		student, err = u.studentRepository.GetStudent(ctx, id)
+	}
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrStudentNotFound
+		}
 		return nil, err
 	}
 
-	return student, nil
+	output := &GetStudentOutput{Student: student}
+
+	if includeCourses {
+		courses, err := u.studentCourses(ctx, id, "")
+		if err != nil {
+			return nil, err
+		}
+		output.Courses = &courses
+	}
+
+	return output, nil
 }
 
 func (u *studentUsecase) UpdateStudent(ctx context.Context, id int, input UpdateStudentInput) (*model.Student, error) {
 	student, err := u.studentRepository.GetStudent(ctx, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrStudentNotFound
+		}
 		return nil, err
 	}
 
 	student.Name = input.Name
 
+	err = setProfile(student, input.Email, input.Phone, input.BirthDate, input.CPF)
+	if err != nil {
+		return nil, err
+	}
+
 	err = u.studentRepository.UpdateStudent(ctx, student)
 	if err != nil {
 		return nil, err
@@ -72,17 +295,139 @@ func (u *studentUsecase) UpdateStudent(ctx context.Context, id int, input Update
 	return student, nil
 }
 
-func (u *studentUsecase) DeleteStudent(ctx context.Context, id int) error {
-	err := u.studentRepository.DeleteStudent(ctx, id)
+// errStudentEnrollmentsChanged is returned when the student enrolled in
+// another course while the courses were being locked
+var errStudentEnrollmentsChanged = errors.New("student enrollments changed while locking")
+
+// DeleteStudent deletes the student. A student enrolled in courses is only
+// deleted with cascade, which first drops them from every course, recording
+// the deletion as the reason, and promotes waitlisted students into the freed
+// seats.
+func (u *studentUsecase) DeleteStudent(ctx context.Context, id int, cascade bool) error {
+	for {
+		err := u.deleteStudent(ctx, id, cascade)
+		if err != errStudentEnrollmentsChanged {
+			return err
+		}
+	}
+}
+
+// deleteStudent locks the courses of the student before the student, the
+// same order used by enrollment. When the student enrolls somewhere else
+// before their row is locked, that course can't be locked anymore without
+// breaking the order, so the transaction is rolled back and
+// errStudentEnrollmentsChanged returned to start over.
+func (u *studentUsecase) deleteStudent(ctx context.Context, id int, cascade bool) error {
+	return u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		courseIDs, err := u.studentRepository.GetEnrolledCourseIDs(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if len(courseIDs) > 0 && !cascade {
+			return &ErrStudentHasEnrollments{Enrollments: len(courseIDs)}
+		}
+
+		for _, courseID := range courseIDs {
+			err = u.courseRepository.LockCourse(ctx, courseID)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = u.studentRepository.LockStudent(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// Nothing changes the enrollments of the student from now on
+		enrolledIDs, err := u.studentRepository.GetEnrolledCourseIDs(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		for _, courseID := range enrolledIDs {
+			if !slices.Contains(courseIDs, courseID) {
+				return errStudentEnrollmentsChanged
+			}
+		}
+		courseIDs = enrolledIDs
+
+		reason := "student deleted"
+		var offeringIDs []int
+		for _, courseID := range courseIDs {
+			enrollment, err := u.enroller.drop(ctx, courseID, id, model.EnrollmentEventUnenroll, &reason)
+			if err != nil {
+				return err
+			}
+			offeringIDs = append(offeringIDs, int(enrollment.OfferingID))
+		}
+
+		err = u.studentRepository.DeleteStudent(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		for _, offeringID := range offeringIDs {
+			err = u.enroller.promoteFromWaitlist(ctx, offeringID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RestoreStudent brings back a deleted student. The enrollments dropped when
+// they were deleted are not restored.
+func (u *studentUsecase) RestoreStudent(ctx context.Context, id int) (*model.Student, error) {
+	student, err := u.studentRepository.GetStudentIncludingDeleted(ctx, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrStudentNotFound
+		}
+		return nil, err
+	}
+
+	if student.DeletedAt == nil {
+		return nil, ErrStudentNotDeleted
+	}
+
+	err = u.studentRepository.RestoreStudent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	student.DeletedAt = nil
+
+	return student, nil
+}
+
+// PurgeStudents permanently removes the students deleted before
+// deletedBefore, returning how many were removed
+func (u *studentUsecase) PurgeStudents(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var purged int
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		purged, err = u.studentRepository.PurgeStudents(ctx, deletedBefore)
 		return err
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return purged, nil
 }
 
-func (u *studentUsecase) GetStudents(ctx context.Context, search string) ([]*model.Student, error) {
-	students, err := u.studentRepository.GetStudents(ctx, search)
+// GetStudents lists the students matching the search on their name, email or
+// CPF. A formatted CPF is searched the way it is stored.
+func (u *studentUsecase) GetStudents(ctx context.Context, search string, includeDeleted bool) ([]*model.Student, error) {
+	if cpf, ok := normalizeCPF(strings.TrimSpace(search)); ok {
+		search = cpf
+	}
+
+	students, err := u.studentRepository.GetStudents(ctx, search, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -97,3 +442,154 @@ func (u *studentUsecase) GetStudents(ctx context.Context, search string) ([]*mod
 
 	return students, nil
 }
+
+// SetEnrollmentLimit overrides the default enrollment limit for the student.
+// Students already above the new limit keep their enrollments.
+func (u *studentUsecase) SetEnrollmentLimit(ctx context.Context, id int, input SetEnrollmentLimitInput) error {
+	if input.MaxCourses < 0 {
+		return ErrInvalidEnrollmentLimit
+	}
+
+	return u.studentRepository.SetMaxCourses(ctx, id, &input.MaxCourses)
+}
+
+// ClearEnrollmentLimit makes the student fall back to the default limit
+func (u *studentUsecase) ClearEnrollmentLimit(ctx context.Context, id int) error {
+	return u.studentRepository.SetMaxCourses(ctx, id, nil)
+}
+
+// GetStudentCourses lists the courses the student is currently enrolled in
+func (u *studentUsecase) GetStudentCourses(ctx context.Context, id int, search string) ([]*StudentCourseOutput, error) {
+	_, err := u.studentRepository.GetStudent(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrStudentNotFound
+		}
+		return nil, err
+	}
+
+	return u.studentCourses(ctx, id, search)
+}
+
+// studentCourses returns the current courses of the student along with their
+// attendance in each of them
+func (u *studentUsecase) studentCourses(ctx context.Context, id int, search string) ([]*StudentCourseOutput, error) {
+	courses, err := u.studentRepository.GetStudentCourses(ctx, id, search)
+	if err != nil {
+		return nil, err
+	}
+
+	attendance, err := u.attendanceRepository.GetStudentAttendance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(courses))
+	for i, course := range courses {
+		ids[i] = course.ID
+	}
+	attendance = evaluateAttendance(attendance, ids, u.minAttendance)
+
+	output := make([]*StudentCourseOutput, len(courses))
+	for i, course := range courses {
+		output[i] = &StudentCourseOutput{Course: course, Attendance: attendance[course.ID]}
+	}
+
+	return output, nil
+}
+
+// TransferStudent moves the student from one course to another in a single
+// transaction. The enrollment in the course being left is dropped first, so it
+// doesn't count against the student limit nor clash with the new schedule. If
+// the student can't be enrolled in the new course nothing changes.
+func (u *studentUsecase) TransferStudent(ctx context.Context, id int, input TransferStudentInput, opts EnrollmentOptions) (*model.Enrollment, error) {
+	if input.FromCourseID == input.ToCourseID {
+		return nil, ErrTransferSameCourse
+	}
+
+	var enrollment *model.Enrollment
+
+	err := u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Both courses are locked in ascending order so concurrent transfers
+		// between the same courses can't deadlock
+		courseIDs := []int{input.FromCourseID, input.ToCourseID}
+		if courseIDs[0] > courseIDs[1] {
+			courseIDs[0], courseIDs[1] = courseIDs[1], courseIDs[0]
+		}
+
+		for _, courseID := range courseIDs {
+			err := u.courseRepository.LockCourse(ctx, courseID)
+			if err != nil {
+				return err
+			}
+		}
+
+		toOffering, err := u.transferTarget(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		current, err := u.courseRepository.GetEnrollment(ctx, input.FromCourseID, id)
+		if err != nil {
+			return err
+		}
+
+		fromOffering, err := u.courseRepository.GetOffering(ctx, int(current.OfferingID))
+		if err != nil {
+			return err
+		}
+
+		err = checkEnrollmentWindow(fromOffering, opts)
+		if err != nil {
+			return err
+		}
+
+		err = u.studentRepository.LockStudent(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		reason := fmt.Sprintf("transferred to course %d", input.ToCourseID)
+		_, err = u.enroller.drop(ctx, input.FromCourseID, id, model.EnrollmentEventTransfer, &reason)
+		if err != nil {
+			return err
+		}
+
+		err = u.enroller.enroll(ctx, int(toOffering.ID), id, opts, model.EnrollmentEventTransfer)
+		if err != nil {
+			return err
+		}
+
+		err = u.enroller.promoteFromWaitlist(ctx, int(fromOffering.ID))
+		if err != nil {
+			return err
+		}
+
+		enrollment, err = u.courseRepository.GetEnrollment(ctx, input.ToCourseID, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+// transferTarget returns the offering of the new course the student is moving
+// to, which must belong to it
+func (u *studentUsecase) transferTarget(ctx context.Context, input TransferStudentInput) (*model.CourseOffering, error) {
+	if input.ToOfferingID == nil {
+		return u.courseRepository.GetDefaultOffering(ctx, input.ToCourseID)
+	}
+
+	offering, err := u.courseRepository.GetOffering(ctx, *input.ToOfferingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if int(offering.CourseID) != input.ToCourseID {
+		return nil, ErrOfferingNotFound
+	}
+
+	return offering, nil
+}
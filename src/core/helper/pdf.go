@@ -0,0 +1,86 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PDFText is a line of text placed on a PDF page. Y is measured in points from
+// the bottom of the page.
+type PDFText struct {
+	Text string
+	Size float64
+	Bold bool
+	Y    float64
+}
+
+// WritePDF writes a single page PDF with every text centered horizontally. It
+// only uses the standard Helvetica fonts, so nothing has to be embedded.
+func WritePDF(w io.Writer, width, height float64, texts []PDFText) error {
+	var content bytes.Buffer
+	for _, text := range texts {
+		font := "F1"
+		if text.Bold {
+			font = "F2"
+		}
+
+		encoded := pdfEncode(text.Text)
+		x := (width - textWidth(encoded, text.Size)) / 2
+
+		fmt.Fprintf(&content, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, text.Size, x, text.Y, pdfEscape(encoded))
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", width, height),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var doc bytes.Buffer
+	doc.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = doc.Len()
+		fmt.Fprintf(&doc, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := doc.Len()
+	fmt.Fprintf(&doc, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&doc, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&doc, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(doc.Bytes())
+	return err
+}
+
+// pdfEncode converts the text to Latin-1, which WinAnsiEncoding matches for the
+// accented letters. Characters out of it are replaced by '?'.
+func pdfEncode(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r > 0xff || (r >= 0x80 && r < 0xa0) {
+			r = '?'
+		}
+		b.WriteByte(byte(r))
+	}
+	return b.String()
+}
+
+func pdfEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(text)
+}
+
+// textWidth estimates the width of the text in points. Helvetica glyphs are
+// about half as wide as the font size on average, which is close enough to
+// center a line.
+func textWidth(text string, size float64) float64 {
+	return float64(len(text)) * size * 0.5
+}
@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+const (
+	AttendancePresent = "present"
+	AttendanceAbsent  = "absent"
+	AttendanceExcused = "excused"
+)
+
+// CourseSession is a single class of an offering of a course
+type CourseSession struct {
+	ID         int64     `json:"id"`
+	CourseID   int64     `json:"course_id"`
+	OfferingID int64     `json:"offering_id"`
+	HeldAt     time.Time `json:"held_at"`
+	Topic      string    `json:"topic"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Attendance is the presence of an enrolled student at a session
+type Attendance struct {
+	SessionID    int64     `json:"session_id"`
+	EnrollmentID int64     `json:"enrollment_id"`
+	StudentID    int64     `json:"student_id"`
+	Status       string    `json:"status"`
+	RecordedAt   time.Time `json:"recorded_at"`
+}
+
+// AttendanceSummary counts the attendance records of a student in a course.
+// Excused absences don't count against the student, so Percentage is the share
+// of present records among present and absent ones. It is nil while there is
+// nothing to compute it from.
+type AttendanceSummary struct {
+	Present    int      `json:"present"`
+	Absent     int      `json:"absent"`
+	Excused    int      `json:"excused"`
+	Percentage *float64 `json:"percentage"`
+	// AtRisk is set when Percentage is below the minimum attendance
+	AtRisk bool `json:"at_risk"`
+}
+
+// Add counts n records with the given status
+func (s *AttendanceSummary) Add(status string, n int) {
+	switch status {
+	case AttendancePresent:
+		s.Present += n
+	case AttendanceAbsent:
+		s.Absent += n
+	case AttendanceExcused:
+		s.Excused += n
+	}
+}
+
+// Evaluate fills Percentage and AtRisk given the minimum attendance percentage
+func (s *AttendanceSummary) Evaluate(minPercentage float64) {
+	s.Percentage = nil
+	s.AtRisk = false
+
+	total := s.Present + s.Absent
+	if total == 0 {
+		return
+	}
+
+	percentage := float64(s.Present) * 100 / float64(total)
+	s.Percentage = &percentage
+	s.AtRisk = percentage < minPercentage
+}
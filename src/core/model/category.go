@@ -0,0 +1,12 @@
+package model
+
+// Category groups courses in the catalog. Categories nest, a category without
+// a parent being at the top of the hierarchy.
+type Category struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	ParentID *int64 `json:"parent_id"`
+	// Path lists the names from the top of the hierarchy down to the
+	// category, like "Technology > Programming"
+	Path string `json:"path"`
+}
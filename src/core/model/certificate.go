@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Certificate attests that a student completed a course. Code identifies the
+// enrollment and is signed by the server, so it can't be forged.
+type Certificate struct {
+	Code        string    `json:"code"`
+	StudentID   int64     `json:"student_id"`
+	StudentName string    `json:"student_name"`
+	CourseID    int64     `json:"course_id"`
+	CourseName  string    `json:"course_name"`
+	CompletedAt time.Time `json:"completed_at"`
+}
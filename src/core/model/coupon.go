@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+const (
+	DiscountTypePercent = "percent"
+	DiscountTypeFixed   = "fixed"
+)
+
+// Coupon is a discount code students apply when enrolling in a paid course.
+// Percent coupons hold the percentage in Value, fixed coupons an amount in the
+// minor unit of their currency.
+type Coupon struct {
+	ID           int64   `json:"id"`
+	Code         string  `json:"code"`
+	DiscountType string  `json:"discount_type"`
+	Value        int64   `json:"value"`
+	Currency     *string `json:"currency"`
+	// MaxRedemptions caps how many enrollments can use the coupon, nil means
+	// it is unlimited
+	MaxRedemptions *int       `json:"max_redemptions"`
+	Redemptions    int        `json:"redemptions"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	// CourseIDs restricts the coupon to these courses, empty means it applies
+	// to every course
+	CourseIDs []int64   `json:"course_ids"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Valid reports whether the coupon can be used at the given time
+func (c *Coupon) Valid(now time.Time) bool {
+	if c.ValidFrom != nil && now.Before(*c.ValidFrom) {
+		return false
+	}
+
+	if c.ValidUntil != nil && !now.Before(*c.ValidUntil) {
+		return false
+	}
+
+	return true
+}
+
+// Discount returns how much the coupon takes off the amount, never more than
+// the amount itself
+func (c *Coupon) Discount(amount int64) int64 {
+	discount := c.Value
+	if c.DiscountType == DiscountTypePercent {
+		discount = amount * c.Value / 100
+	}
+
+	return min(discount, amount)
+}
+
+// Scholarship is a percentage discount granted to a student, on one course
+// or, without a course, on every course
+type Scholarship struct {
+	ID         int64      `json:"id"`
+	StudentID  int64      `json:"student_id"`
+	CourseID   *int64     `json:"course_id"`
+	Percent    int        `json:"percent"`
+	Reason     string     `json:"reason"`
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Discount returns how much the scholarship takes off the amount
+func (s *Scholarship) Discount(amount int64) int64 {
+	return amount * int64(s.Percent) / 100
+}
@@ -1,7 +1,60 @@
 package model
 
+import "time"
+
+const (
+	CourseStatusDraft     = "draft"
+	CourseStatusPublished = "published"
+	CourseStatusArchived  = "archived"
+)
+
+// Course is an entry of the catalog. The classes actually run for it, each
+// with its own dates, capacity and roster, are its offerings. Only published
+// courses accept enrollments.
 type Course struct {
-	ID          int64  `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	CategoryID  *int64   `json:"category_id"`
+	Tags        []string `json:"tags"`
+	// Price is in the minor unit of the currency, like cents. Free courses
+	// have a zero price.
+	Price       int64      `json:"price"`
+	Currency    string     `json:"currency"`
+	Status      string     `json:"status"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	// DeletedAt is set while the course is deleted, until it is restored or
+	// purged
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// CourseOffering is a run of a course, like a section or a cohort. Enrollments
+// made through the course itself go to its default offering.
+type CourseOffering struct {
+	ID       int64      `json:"id"`
+	CourseID int64      `json:"course_id"`
+	Name     string     `json:"name"`
+	StartsOn *time.Time `json:"starts_on"`
+	EndsOn   *time.Time `json:"ends_on"`
+	Capacity int        `json:"capacity"`
+	// A nil bound leaves that side of the enrollment window open
+	EnrollmentOpensAt  *time.Time `json:"enrollment_opens_at"`
+	EnrollmentClosesAt *time.Time `json:"enrollment_closes_at"`
+	IsDefault          bool       `json:"is_default"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// EnrollmentOpen reports whether the offering accepts enrollment changes at
+// the given time
+func (o *CourseOffering) EnrollmentOpen(now time.Time) bool {
+	if o.EnrollmentOpensAt != nil && now.Before(*o.EnrollmentOpensAt) {
+		return false
+	}
+
+	if o.EnrollmentClosesAt != nil && !now.Before(*o.EnrollmentClosesAt) {
+		return false
+	}
+
+	return true
 }
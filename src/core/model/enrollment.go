@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+const (
+	EnrollmentStatusPending   = "pending"
+	EnrollmentStatusActive    = "active"
+	EnrollmentStatusCompleted = "completed"
+	EnrollmentStatusDropped   = "dropped"
+	EnrollmentStatusWithdrawn = "withdrawn"
+)
+
+type Enrollment struct {
+	ID          int64      `json:"id"`
+	CourseID    int64      `json:"course_id"`
+	OfferingID  int64      `json:"offering_id"`
+	StudentID   int64      `json:"student_id"`
+	Status      string     `json:"status"`
+	Reason      *string    `json:"reason,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	PendingAt   *time.Time `json:"pending_at,omitempty"`
+	ActivatedAt *time.Time `json:"activated_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	DroppedAt   *time.Time `json:"dropped_at,omitempty"`
+	WithdrawnAt *time.Time `json:"withdrawn_at,omitempty"`
+}
+
+// Ongoing reports whether the enrollment takes a seat in the course and counts
+// toward the student enrollment limit
+func (e *Enrollment) Ongoing() bool {
+	return e.Status == EnrollmentStatusPending || e.Status == EnrollmentStatusActive
+}
+
+const (
+	EnrollmentEventEnroll       = "enroll"
+	EnrollmentEventUnenroll     = "unenroll"
+	EnrollmentEventTransfer     = "transfer"
+	EnrollmentEventStatusChange = "status_change"
+)
+
+// EnrollmentEvent is an entry of the append-only enrollment history. Status is
+// the status of the enrollment right after the event.
+type EnrollmentEvent struct {
+	ID           int64     `json:"id"`
+	EnrollmentID int64     `json:"enrollment_id"`
+	CourseID     int64     `json:"course_id"`
+	StudentID    int64     `json:"student_id"`
+	Event        string    `json:"event"`
+	Status       string    `json:"status"`
+	Reason       *string   `json:"reason,omitempty"`
+	Actor        string    `json:"actor"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+const (
+	MinGrade = 0
+	MaxGrade = 10
+)
+
+// gradeConcepts maps the lowest grade of each band of the scale to its
+// concept, from the highest band down
+var gradeConcepts = []struct {
+	Min     float64
+	Concept string
+}{
+	{9, "A"},
+	{7, "B"},
+	{6, "C"},
+	{4, "D"},
+	{MinGrade, "F"},
+}
+
+// GradeConcept returns the letter concept of a grade in the 0 to 10 scale
+func GradeConcept(value float64) string {
+	for _, band := range gradeConcepts {
+		if value >= band.Min {
+			return band.Concept
+		}
+	}
+	return "F"
+}
+
+type Grade struct {
+	ID           int64      `json:"id"`
+	EnrollmentID int64      `json:"enrollment_id"`
+	Value        float64    `json:"value"`
+	Concept      string     `json:"concept"`
+	Finalized    bool       `json:"finalized"`
+	FinalizedAt  *time.Time `json:"finalized_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// GradeCorrection records a change made to a grade after it was finalized
+type GradeCorrection struct {
+	ID        int64     `json:"id"`
+	GradeID   int64     `json:"grade_id"`
+	OldValue  float64   `json:"old_value"`
+	NewValue  float64   `json:"new_value"`
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TranscriptEntry is a completed course in the transcript of a student. Grade
+// is nil while the course hasn't been graded.
+type TranscriptEntry struct {
+	CourseID    int64      `json:"course_id"`
+	CourseName  string     `json:"course_name"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Grade       *float64   `json:"grade"`
+	Concept     string     `json:"concept,omitempty"`
+	Finalized   bool       `json:"finalized"`
+}
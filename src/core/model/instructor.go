@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+const (
+	InstructorRoleLead      = "lead"
+	InstructorRoleAssistant = "assistant"
+)
+
+type Instructor struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// CourseInstructor is an instructor assigned to a course
+type CourseInstructor struct {
+	*Instructor
+	Role       string    `json:"role"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+// InstructorCourse is a course an instructor is assigned to
+type InstructorCourse struct {
+	*Course
+	Role       string    `json:"role"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
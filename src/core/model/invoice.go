@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+const (
+	InvoiceStatusOpen     = "open"
+	InvoiceStatusPaid     = "paid"
+	InvoiceStatusExpired  = "expired"
+	InvoiceStatusCanceled = "canceled"
+	// InvoiceStatusPaidLate marks an invoice paid at the gateway after it
+	// expired or was canceled. The payment is refunded.
+	InvoiceStatusPaidLate = "paid_late"
+	InvoiceStatusRefunded = "refunded"
+)
+
+// Invoice charges a student for a paid enrollment, which stays pending until
+// the invoice is paid. Amount is what is left to pay once Discount, from the
+// scholarship and coupon of the enrollment, is taken off the course price.
+// Both are in the minor unit of the currency. Reference and PaymentURL are
+// only filled once the gateway creates the charge, after the enrollment
+// commits.
+type Invoice struct {
+	ID            int64      `json:"id"`
+	EnrollmentID  int64      `json:"enrollment_id"`
+	StudentID     int64      `json:"student_id"`
+	CourseID      int64      `json:"course_id"`
+	Amount        int64      `json:"amount"`
+	Discount      int64      `json:"discount"`
+	CouponID      *int64     `json:"coupon_id,omitempty"`
+	ScholarshipID *int64     `json:"scholarship_id,omitempty"`
+	Currency      string     `json:"currency"`
+	Status        string     `json:"status"`
+	Gateway       string     `json:"gateway"`
+	Reference     *string    `json:"reference,omitempty"`
+	PaymentURL    *string    `json:"payment_url,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+	ExpiredAt     *time.Time `json:"expired_at,omitempty"`
+	CanceledAt    *time.Time `json:"canceled_at,omitempty"`
+	RefundedAt    *time.Time `json:"refunded_at,omitempty"`
+}
+
+const (
+	PaymentTaskCreateCharge = "create_charge"
+	PaymentTaskCancelCharge = "cancel_charge"
+	PaymentTaskRefundCharge = "refund_charge"
+)
+
+// PaymentTask is a call to the payment gateway still to be made for an
+// invoice. Tasks are recorded in the transaction that needs them and run once
+// it commits, the ones the gateway fails are tried again later.
+type PaymentTask struct {
+	ID          int64      `json:"id"`
+	InvoiceID   int64      `json:"invoice_id"`
+	Kind        string     `json:"kind"`
+	Attempts    int        `json:"attempts"`
+	LastError   *string    `json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
@@ -0,0 +1,14 @@
+package model
+
+// Room is a physical classroom courses meet in
+type Room struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Seats int    `json:"seats"`
+}
+
+// RoomBooking is a course slot taking place in a room
+type RoomBooking struct {
+	*CourseSlot
+	CourseName string `json:"course_name"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// CourseSlot is a weekly meeting time of a course. Weekday follows
+// time.Weekday, 0 being Sunday. Times are formatted as "15:04".
+type CourseSlot struct {
+	ID        int64  `json:"id"`
+	CourseID  int64  `json:"course_id"`
+	Weekday   int    `json:"weekday"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	// RoomID is the room booked for the slot, if any
+	RoomID *int64 `json:"room_id"`
+}
+
+// Overlaps reports whether both slots meet at the same time. Slots that only
+// touch, one ending when the other starts, don't overlap.
+func (s *CourseSlot) Overlaps(other *CourseSlot) bool {
+	return s.Weekday == other.Weekday && s.StartTime < other.EndTime && other.StartTime < s.EndTime
+}
+
+func (s *CourseSlot) String() string {
+	return fmt.Sprintf("%s %s-%s", time.Weekday(s.Weekday), s.StartTime, s.EndTime)
+}
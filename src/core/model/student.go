@@ -1,6 +1,19 @@
 package model
 
+import "time"
+
 type Student struct {
-	ID   int64  `json:"id"`
-	Name string `json:"name"`
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Email     *string    `json:"email"`
+	Phone     *string    `json:"phone"`
+	BirthDate *time.Time `json:"birth_date"`
+	// CPF is the brazilian taxpayer id, stored as its 11 digits without
+	// punctuation
+	CPF *string `json:"cpf"`
+	// MaxCourses overrides the default enrollment limit for this student
+	MaxCourses *int `json:"max_courses,omitempty"`
+	// DeletedAt is set while the student is deleted, until they are restored
+	// or purged
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// WaitlistEntry is a student waiting for a seat in an offering. Position is
+// counted among the students waiting for the same offering.
+type WaitlistEntry struct {
+	ID         int64     `json:"id"`
+	CourseID   int64     `json:"course_id"`
+	OfferingID int64     `json:"offering_id"`
+	StudentID  int64     `json:"student_id"`
+	Position   int       `json:"position"`
+	CreatedAt  time.Time `json:"created_at"`
+}
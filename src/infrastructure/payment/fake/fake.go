@@ -0,0 +1,104 @@
+package fake
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/payment"
+)
+
+// SignatureHeader carries the signature of the notifications sent to the
+// callback endpoint
+const SignatureHeader = "X-Fake-Signature"
+
+// maxNotificationSize bounds the body read from a callback request
+const maxNotificationSize = 1 << 20
+
+// FakeGateway is a payment provider for development and tests. It charges
+// nothing, a charge is marked as paid by posting
+// {"reference": "...", "status": "paid"} to the callback endpoint, signed with
+// the secret of the gateway in SignatureHeader.
+type FakeGateway struct {
+	// BaseURL prefixes the payment URLs handed out to students
+	BaseURL string
+	// secret signs the notifications
+	secret []byte
+}
+
+func NewFakeGateway(baseURL string, secret []byte) *FakeGateway {
+	return &FakeGateway{BaseURL: baseURL, secret: secret}
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+// CreateCharge derives the reference of the charge from the idempotency key,
+// so retrying a call hands out the same charge
+func (g *FakeGateway) CreateCharge(ctx context.Context, invoice *model.Invoice, idempotencyKey string) (*payment.Charge, error) {
+	reference := "fake_" + hex.EncodeToString(g.mac([]byte("charge:" + idempotencyKey))[:12])
+
+	return &payment.Charge{
+		Reference:  reference,
+		PaymentURL: g.BaseURL + "/pay/" + reference,
+	}, nil
+}
+
+func (g *FakeGateway) CancelCharge(ctx context.Context, invoice *model.Invoice) error {
+	return nil
+}
+
+func (g *FakeGateway) RefundCharge(ctx context.Context, invoice *model.Invoice, idempotencyKey string) error {
+	return nil
+}
+
+// Sign returns the signature of a notification body, the hex encoded
+// HMAC-SHA256 of it
+func (g *FakeGateway) Sign(body []byte) string {
+	return hex.EncodeToString(g.mac(body))
+}
+
+func (g *FakeGateway) mac(body []byte) []byte {
+	h := hmac.New(sha256.New, g.secret)
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// ParseNotification only accepts notifications signed with the secret of the
+// gateway, so nobody without it can mark a charge as paid
+func (g *FakeGateway) ParseNotification(req *http.Request) (*payment.Notification, error) {
+	raw, err := io.ReadAll(io.LimitReader(req.Body, maxNotificationSize))
+	if err != nil {
+		return nil, payment.ErrInvalidNotification
+	}
+
+	signature, err := hex.DecodeString(req.Header.Get(SignatureHeader))
+	if err != nil || len(g.secret) == 0 {
+		return nil, payment.ErrInvalidNotification
+	}
+
+	if !hmac.Equal(signature, g.mac(raw)) {
+		return nil, payment.ErrInvalidNotification
+	}
+
+	var body struct {
+		Reference string `json:"reference"`
+		Status    string `json:"status"`
+	}
+
+	err = json.Unmarshal(raw, &body)
+	if err != nil || body.Reference == "" {
+		return nil, payment.ErrInvalidNotification
+	}
+
+	return &payment.Notification{
+		Reference: body.Reference,
+		Paid:      body.Status == "paid",
+	}, nil
+}
@@ -0,0 +1,43 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+)
+
+var ErrInvalidNotification = errors.New("invalid payment notification")
+
+// Charge is a request for payment registered with the provider
+type Charge struct {
+	// Reference identifies the charge at the provider, notifications refer
+	// to it
+	Reference string
+	// PaymentURL is where the student pays the charge
+	PaymentURL string
+}
+
+// Notification is a change in a charge reported by the provider
+type Notification struct {
+	Reference string
+	Paid      bool
+}
+
+// IPaymentGateway is a payment provider invoices are charged through
+type IPaymentGateway interface {
+	// Name identifies the provider, it is stored along with the invoices
+	Name() string
+	// CreateCharge registers a charge for the invoice. Calls with the same
+	// idempotencyKey create a single charge and return it again, so a call
+	// retried after its result was lost doesn't charge the student twice.
+	CreateCharge(ctx context.Context, invoice *model.Invoice, idempotencyKey string) (*Charge, error)
+	CancelCharge(ctx context.Context, invoice *model.Invoice) error
+	// RefundCharge gives back what was paid for the charge of the invoice,
+	// with the same idempotency guarantee as CreateCharge
+	RefundCharge(ctx context.Context, invoice *model.Invoice, idempotencyKey string) error
+	// ParseNotification authenticates a callback request sent by the provider
+	// and returns what it reports, or ErrInvalidNotification
+	ParseNotification(req *http.Request) (*Notification, error)
+}
@@ -1,7 +1,35 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/felipedavid/vrcursos/src/core/domain"
+)
 
 var (
-	ErrStudentAlreadyEnrolled = errors.New("student already enrolled")
+	ErrStudentAlreadyEnrolled    = errors.New("student already enrolled")
+	ErrCourseNotFound            = errors.New("course not found")
+	ErrStudentNotFound           = domain.ErrStudentNotFound
+	ErrStudentAlreadyInWaitlist  = errors.New("student already in the waitlist")
+	ErrStudentNotInWaitlist      = errors.New("student not in the waitlist")
+	ErrEnrollmentNotFound        = errors.New("student is not enrolled in the course")
+	ErrPrerequisiteAlreadyAdded  = errors.New("course is already a prerequisite")
+	ErrPrerequisiteNotFound      = errors.New("course is not a prerequisite")
+	ErrSlotNotFound              = errors.New("slot not found")
+	ErrGradeNotFound             = errors.New("grade not found")
+	ErrSessionNotFound           = errors.New("session not found")
+	ErrOfferingNotFound          = errors.New("offering not found")
+	ErrInstructorNotFound        = errors.New("instructor not found")
+	ErrInstructorNotAssigned     = errors.New("instructor is not assigned to the course")
+	ErrRoomNotFound              = errors.New("room not found")
+	ErrCategoryNotFound          = errors.New("category not found")
+	ErrInvoiceNotFound           = errors.New("invoice not found")
+	ErrCouponNotFound            = errors.New("coupon not found")
+	ErrCouponCodeTaken           = errors.New("coupon code is already taken")
+	ErrCouponExhausted           = errors.New("coupon has reached its redemption limit")
+	ErrCouponCapBelowRedemptions = errors.New("max_redemptions is lower than the redemptions already made")
+	ErrScholarshipNotFound       = errors.New("scholarship not found")
+	ErrStudentEmailTaken         = errors.New("email is already in use by another student")
+	ErrStudentCPFTaken           = errors.New("cpf is already in use by another student")
+	ErrPaymentTaskNotFound       = errors.New("payment task not found")
 )
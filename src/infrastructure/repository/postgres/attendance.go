@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type PostgresAttendanceRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAttendanceRepository(db *sql.DB) *PostgresAttendanceRepository {
+	return &PostgresAttendanceRepository{db: db}
+}
+
+func (r PostgresAttendanceRepository) SaveSession(ctx context.Context, session *model.CourseSession) error {
+	query := `INSERT INTO course_session (course_id, offering_id, held_at, topic) VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, session.CourseID, session.OfferingID, session.HeldAt, session.Topic)
+	err := row.Scan(&session.ID, &session.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresAttendanceRepository) GetSession(ctx context.Context, courseID, sessionID int) (*model.CourseSession, error) {
+	query := `SELECT id, course_id, offering_id, held_at, topic, created_at FROM course_session WHERE course_id = $1 AND id = $2`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, courseID, sessionID)
+
+	var session model.CourseSession
+	err := row.Scan(&session.ID, &session.CourseID, &session.OfferingID, &session.HeldAt, &session.Topic, &session.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r PostgresAttendanceRepository) GetSessions(ctx context.Context, courseID int) ([]*model.CourseSession, error) {
+	query := `SELECT id, course_id, offering_id, held_at, topic, created_at FROM course_session WHERE course_id = $1 ORDER BY held_at, id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*model.CourseSession
+
+	for rows.Next() {
+		var session model.CourseSession
+		if err := rows.Scan(&session.ID, &session.CourseID, &session.OfferingID, &session.HeldAt, &session.Topic, &session.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// SaveAttendance records the attendance of an enrollment at a session,
+// replacing the one submitted before, if any
+func (r PostgresAttendanceRepository) SaveAttendance(ctx context.Context, attendance *model.Attendance) error {
+	query := `
+		INSERT INTO attendance (session_id, enrollment_id, status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (session_id, enrollment_id)
+		DO UPDATE SET status = EXCLUDED.status, recorded_at = NOW()
+		RETURNING recorded_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, attendance.SessionID, attendance.EnrollmentID, attendance.Status)
+	err := row.Scan(&attendance.RecordedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresAttendanceRepository) GetSessionAttendance(ctx context.Context, sessionID int) ([]*model.Attendance, error) {
+	query := `
+		SELECT a.session_id, a.enrollment_id, e.student_id, a.status, a.recorded_at
+		FROM attendance a
+		JOIN enrollment e ON e.id = a.enrollment_id
+		WHERE a.session_id = $1
+		ORDER BY e.student_id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*model.Attendance
+
+	for rows.Next() {
+		var attendance model.Attendance
+		if err := rows.Scan(&attendance.SessionID, &attendance.EnrollmentID, &attendance.StudentID, &attendance.Status, &attendance.RecordedAt); err != nil {
+			return nil, err
+		}
+
+		records = append(records, &attendance)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// GetCourseAttendance counts the attendance records of each student of the
+// course, keyed by student id. Only the latest enrollment of each student
+// counts, so the classes of a dropped or earlier enrollment don't carry over.
+func (r PostgresAttendanceRepository) GetCourseAttendance(ctx context.Context, courseID int) (map[int64]*model.AttendanceSummary, error) {
+	query := `
+		WITH current AS (
+			SELECT DISTINCT ON (student_id) id, student_id
+			FROM enrollment
+			WHERE course_id = $1
+			ORDER BY student_id, created_at DESC, id DESC
+		)
+		SELECT c.student_id, a.status, COUNT(*)
+		FROM attendance a
+		JOIN current c ON c.id = a.enrollment_id
+		GROUP BY c.student_id, a.status`
+
+	return r.querySummaries(ctx, query, courseID)
+}
+
+// GetStudentAttendance counts the attendance records of the student in each
+// course, keyed by course id. Only the latest enrollment in each course counts.
+func (r PostgresAttendanceRepository) GetStudentAttendance(ctx context.Context, studentID int) (map[int64]*model.AttendanceSummary, error) {
+	query := `
+		WITH current AS (
+			SELECT DISTINCT ON (course_id) id, course_id
+			FROM enrollment
+			WHERE student_id = $1
+			ORDER BY course_id, created_at DESC, id DESC
+		)
+		SELECT c.course_id, a.status, COUNT(*)
+		FROM attendance a
+		JOIN current c ON c.id = a.enrollment_id
+		GROUP BY c.course_id, a.status`
+
+	return r.querySummaries(ctx, query, studentID)
+}
+
+func (r PostgresAttendanceRepository) querySummaries(ctx context.Context, query string, args ...any) (map[int64]*model.AttendanceSummary, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := map[int64]*model.AttendanceSummary{}
+
+	for rows.Next() {
+		var id int64
+		var status string
+		var count int
+		if err := rows.Scan(&id, &status, &count); err != nil {
+			return nil, err
+		}
+
+		summary, ok := summaries[id]
+		if !ok {
+			summary = &model.AttendanceSummary{}
+			summaries[id] = summary
+		}
+		summary.Add(status, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
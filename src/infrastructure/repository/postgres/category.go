@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+// categoryTree selects every category along with its path from the top of the
+// hierarchy
+const categoryTree = `
+	WITH RECURSIVE tree(id, name, parent_id, path) AS (
+		SELECT id, name, parent_id, name FROM category WHERE parent_id IS NULL
+		UNION ALL
+		SELECT c.id, c.name, c.parent_id, tree.path || ' > ' || c.name
+		FROM category c
+		JOIN tree ON c.parent_id = tree.id
+	)`
+
+type PostgresCategoryRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresCategoryRepository(db *sql.DB) *PostgresCategoryRepository {
+	return &PostgresCategoryRepository{db: db}
+}
+
+func (r PostgresCategoryRepository) Save(ctx context.Context, category *model.Category) error {
+	query := `INSERT INTO category (name, parent_id) VALUES ($1, $2) RETURNING id`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, category.Name, category.ParentID)
+	err := row.Scan(&category.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetCategories returns every category ordered by path, so subcategories
+// follow their parent
+func (r PostgresCategoryRepository) GetCategories(ctx context.Context) ([]*model.Category, error) {
+	query := categoryTree + ` SELECT id, name, parent_id, path FROM tree ORDER BY path`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*model.Category
+
+	for rows.Next() {
+		var category model.Category
+		if err := rows.Scan(&category.ID, &category.Name, &category.ParentID, &category.Path); err != nil {
+			return nil, err
+		}
+		categories = append(categories, &category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (r PostgresCategoryRepository) GetCategory(ctx context.Context, id int) (*model.Category, error) {
+	query := categoryTree + ` SELECT id, name, parent_id, path FROM tree WHERE id = $1`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id)
+
+	var category model.Category
+	if err := row.Scan(&category.ID, &category.Name, &category.ParentID, &category.Path); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r PostgresCategoryRepository) UpdateCategory(ctx context.Context, category *model.Category) error {
+	query := `UPDATE category SET name = $1, parent_id = $2 WHERE id = $3`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, category.Name, category.ParentID, category.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresCategoryRepository) DeleteCategory(ctx context.Context, id int) error {
+	query := `DELETE FROM category WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrCategoryNotFound
+	}
+
+	return nil
+}
+
+// LockCategories blocks concurrent changes to the category hierarchy until the
+// surrounding transaction ends, so two moves can't form a cycle together
+func (r PostgresCategoryRepository) LockCategories(ctx context.Context) error {
+	query := `LOCK TABLE category IN SHARE ROW EXCLUSIVE MODE`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsDescendant reports whether the category id is ancestorID itself or one of
+// its subcategories, at any depth
+func (r PostgresCategoryRepository) IsDescendant(ctx context.Context, id, ancestorID int) (bool, error) {
+	query := `
+		WITH RECURSIVE subtree(id) AS (
+			SELECT $2::INT
+			UNION
+			SELECT c.id FROM category c JOIN subtree ON c.parent_id = subtree.id
+		)
+		SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $1)`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id, ancestorID)
+	var descendant bool
+	if err := row.Scan(&descendant); err != nil {
+		return false, err
+	}
+
+	return descendant, nil
+}
+
+// CountCourses returns how many courses are directly in the category
+func (r PostgresCategoryRepository) CountCourses(ctx context.Context, id int) (int, error) {
+	query := `SELECT COUNT(*) FROM course WHERE category_id = $1`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ReassignCourses moves every course of the category fromID to toID
+func (r PostgresCategoryRepository) ReassignCourses(ctx context.Context, fromID, toID int) error {
+	query := `UPDATE course SET category_id = $1 WHERE category_id = $2`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, toID, fromID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReparentChildren moves the subcategories of the category under parentID
+func (r PostgresCategoryRepository) ReparentChildren(ctx context.Context, id int, parentID *int64) error {
+	query := `UPDATE category SET parent_id = $1 WHERE parent_id = $2`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, parentID, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
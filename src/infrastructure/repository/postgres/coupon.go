@@ -0,0 +1,220 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+	"github.com/lib/pq"
+)
+
+type PostgresCouponRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresCouponRepository(db *sql.DB) *PostgresCouponRepository {
+	return &PostgresCouponRepository{db: db}
+}
+
+const couponColumns = `c.id, c.code, c.discount_type, c.value, c.currency, c.max_redemptions, c.redemptions,
+	c.valid_from, c.valid_until, c.created_at,
+	ARRAY(SELECT cc.course_id FROM coupon_course cc WHERE cc.coupon_id = c.id ORDER BY cc.course_id)`
+
+func scanCoupon(scanner interface{ Scan(...any) error }) (*model.Coupon, error) {
+	var c model.Coupon
+	err := scanner.Scan(&c.ID, &c.Code, &c.DiscountType, &c.Value, &c.Currency, &c.MaxRedemptions, &c.Redemptions,
+		&c.ValidFrom, &c.ValidUntil, &c.CreatedAt, pq.Array(&c.CourseIDs))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrCouponNotFound
+		}
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r PostgresCouponRepository) SaveCoupon(ctx context.Context, coupon *model.Coupon) error {
+	query := `
+		INSERT INTO coupon (code, discount_type, value, currency, max_redemptions, valid_from, valid_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, redemptions, created_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, coupon.Code, coupon.DiscountType, coupon.Value, coupon.Currency,
+		coupon.MaxRedemptions, coupon.ValidFrom, coupon.ValidUntil)
+	err := row.Scan(&coupon.ID, &coupon.Redemptions, &coupon.CreatedAt)
+	if err != nil {
+		if constraint, ok := uniqueViolation(err); ok && constraint == "coupon_code_key" {
+			return repository.ErrCouponCodeTaken
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresCouponRepository) GetCoupons(ctx context.Context) ([]*model.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupon c ORDER BY c.id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var coupons []*model.Coupon
+
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, coupon)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return coupons, nil
+}
+
+func (r PostgresCouponRepository) GetCoupon(ctx context.Context, id int) (*model.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupon c WHERE c.id = $1`
+
+	return scanCoupon(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
+func (r PostgresCouponRepository) GetCouponByCode(ctx context.Context, code string) (*model.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupon c WHERE c.code = $1`
+
+	return scanCoupon(conn(ctx, r.db).QueryRowContext(ctx, query, code))
+}
+
+// UpdateCoupon replaces the terms of the coupon. Its redemption count is left
+// alone, and lowering the cap below it is rejected by the database.
+func (r PostgresCouponRepository) UpdateCoupon(ctx context.Context, coupon *model.Coupon) error {
+	query := `
+		UPDATE coupon
+		SET discount_type = $1, value = $2, currency = $3, max_redemptions = $4, valid_from = $5, valid_until = $6
+		WHERE id = $7`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, coupon.DiscountType, coupon.Value, coupon.Currency,
+		coupon.MaxRedemptions, coupon.ValidFrom, coupon.ValidUntil, coupon.ID)
+	if err != nil {
+		if constraint, ok := checkViolation(err); ok && constraint == "coupon_redemptions_valid" {
+			return repository.ErrCouponCapBelowRedemptions
+		}
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrCouponNotFound
+	}
+
+	return nil
+}
+
+func (r PostgresCouponRepository) DeleteCoupon(ctx context.Context, id int) error {
+	query := `DELETE FROM coupon WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrCouponNotFound
+	}
+
+	return nil
+}
+
+// SetCouponCourses replaces the courses the coupon is restricted to
+func (r PostgresCouponRepository) SetCouponCourses(ctx context.Context, couponID int64, courseIDs []int64) error {
+	query := `DELETE FROM coupon_course WHERE coupon_id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, couponID)
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO coupon_course (coupon_id, course_id) SELECT $1, UNNEST($2::INT[])`
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query, couponID, pq.Array(courseIDs))
+	if err != nil {
+		if constraint, ok := foreignKeyViolation(err); ok && constraint == "coupon_course_course_id_fkey" {
+			return repository.ErrCourseNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RedeemCoupon counts one more use of the coupon. The cap is checked by the
+// update itself, which holds the row lock, so concurrent redemptions can never
+// go past it.
+func (r PostgresCouponRepository) RedeemCoupon(ctx context.Context, couponID int64) error {
+	query := `
+		UPDATE coupon SET redemptions = redemptions + 1
+		WHERE id = $1 AND (max_redemptions IS NULL OR redemptions < max_redemptions)`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, couponID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrCouponExhausted
+	}
+
+	return nil
+}
+
+// SaveRedemption records the discount the coupon gave to the enrollment
+func (r PostgresCouponRepository) SaveRedemption(ctx context.Context, couponID, enrollmentID, discount int64) error {
+	query := `INSERT INTO coupon_redemption (coupon_id, enrollment_id, discount) VALUES ($1, $2, $3)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, couponID, enrollmentID, discount)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReleaseRedemption gives back the use of the coupon redeemed by the
+// enrollment, if any
+func (r PostgresCouponRepository) ReleaseRedemption(ctx context.Context, enrollmentID int64) error {
+	query := `
+		WITH released AS (
+			DELETE FROM coupon_redemption WHERE enrollment_id = $1 RETURNING coupon_id
+		)
+		UPDATE coupon SET redemptions = redemptions - 1
+		WHERE id IN (SELECT coupon_id FROM released)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, enrollmentID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -3,9 +3,14 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/felipedavid/vrcursos/src/core/model"
 	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+	"github.com/lib/pq"
 )
 
 type PostgresCourseRepository struct {
@@ -16,10 +21,25 @@ func NewPostgresCourseRepository(db *sql.DB) *PostgresCourseRepository {
 	return &PostgresCourseRepository{db: db}
 }
 
+// courseColumns selects a course row aliased as c along with its tags
+const courseColumns = `c.id, c.description, c.name, c.category_id, ARRAY(SELECT t.tag FROM course_tag t WHERE t.course_id = c.id ORDER BY t.tag), c.price, c.currency,
+	c.status, c.published_at, c.archived_at, c.deleted_at`
+
+func scanCourse(scanner interface{ Scan(...any) error }) (*model.Course, error) {
+	var course model.Course
+	err := scanner.Scan(&course.ID, &course.Description, &course.Name, &course.CategoryID, pq.Array(&course.Tags), &course.Price, &course.Currency,
+		&course.Status, &course.PublishedAt, &course.ArchivedAt, &course.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &course, nil
+}
+
 func (r PostgresCourseRepository) Save(ctx context.Context, course *model.Course) error {
-	query := `INSERT INTO course (description, name) VALUES ($1, $2) RETURNING id`
+	query := `INSERT INTO course (description, name, category_id, price, currency, status) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
 
-	row := r.db.QueryRow(query, course.Description, course.Name)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, course.Description, course.Name, course.CategoryID, course.Price, course.Currency, course.Status)
 	err := row.Scan(&course.ID)
 	if err != nil {
 		return err
@@ -28,49 +48,126 @@ func (r PostgresCourseRepository) Save(ctx context.Context, course *model.Course
 	return nil
 }
 
-func (r PostgresCourseRepository) GetCourses(ctx context.Context) ([]*model.Course, error) {
-	query := `SELECT id, description, name FROM course`
+// GetCourses returns the courses matching the filter. Filtering by category
+// also matches the courses of its subcategories, and every tag of the filter
+// must be on the course.
+func (r PostgresCourseRepository) GetCourses(ctx context.Context, filter repository.CourseFilter) ([]*model.Course, error) {
+	query := `SELECT ` + courseColumns + ` FROM course c`
+	args := []any{}
+	conditions := []string{}
 
-	rows, err := r.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, `c.deleted_at IS NULL`)
 	}
-	defer rows.Close()
 
-	var courses []*model.Course
+	if filter.CategoryID != nil {
+		args = append(args, *filter.CategoryID)
+		conditions = append(conditions, fmt.Sprintf(`c.category_id IN (
+			WITH RECURSIVE subtree(id) AS (
+				SELECT $%d::INT
+				UNION
+				SELECT child.id FROM category child JOIN subtree ON child.parent_id = subtree.id
+			)
+			SELECT id FROM subtree
+		)`, len(args)))
+	}
 
-	for rows.Next() {
-		var course model.Course
-		if err := rows.Scan(&course.ID, &course.Description, &course.Name); err != nil {
-			return nil, err
-		}
+	for _, tag := range filter.Tags {
+		args = append(args, tag)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM course_tag t WHERE t.course_id = c.id AND t.tag = $%d)`, len(args)))
+	}
 
-		courses = append(courses, &course)
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		conditions = append(conditions, fmt.Sprintf(`c.status = ANY($%d)`, len(args)))
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
 	}
 
-	return courses, nil
+	query += ` ORDER BY c.id`
+
+	return r.queryCourses(ctx, query, args...)
 }
 
+// GetCourse returns the course unless it is deleted
 func (r PostgresCourseRepository) GetCourse(ctx context.Context, id int) (*model.Course, error) {
-	query := `SELECT id, description, name FROM course WHERE id = $1`
+	query := `SELECT ` + courseColumns + ` FROM course c WHERE c.id = $1 AND c.deleted_at IS NULL`
 
-	row := r.db.QueryRowContext(ctx, query, id)
-	var course model.Course
-	if err := row.Scan(&course.ID, &course.Description, &course.Name); err != nil {
-		return nil, err
+	return scanCourse(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
+// GetCourseIncludingDeleted returns the course whether it is deleted or not
+func (r PostgresCourseRepository) GetCourseIncludingDeleted(ctx context.Context, id int) (*model.Course, error) {
+	query := `SELECT ` + courseColumns + ` FROM course c WHERE c.id = $1`
+
+	return scanCourse(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
+// courseStatusColumns maps the statuses a course is moved to with the column
+// holding the time it last entered them
+var courseStatusColumns = map[string]string{
+	model.CourseStatusPublished: "published_at",
+	model.CourseStatusArchived:  "archived_at",
+}
+
+// UpdateCourseStatus moves the course to its status, stamping the time of the
+// transition
+func (r PostgresCourseRepository) UpdateCourseStatus(ctx context.Context, course *model.Course) error {
+	column, ok := courseStatusColumns[course.Status]
+	if !ok {
+		return fmt.Errorf("unknown course status %q", course.Status)
 	}
 
-	return &course, nil
+	query := fmt.Sprintf(`
+		UPDATE course SET status = $1, %s = NOW()
+		WHERE id = $2
+		RETURNING %s`, column, column)
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, course.Status, course.ID)
+
+	var changedAt time.Time
+	if err := row.Scan(&changedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrCourseNotFound
+		}
+		return err
+	}
+
+	switch course.Status {
+	case model.CourseStatusPublished:
+		course.PublishedAt = &changedAt
+	case model.CourseStatusArchived:
+		course.ArchivedAt = &changedAt
+	}
+
+	return nil
 }
 
 func (r PostgresCourseRepository) UpdateCourse(ctx context.Context, course *model.Course) error {
-	query := `UPDATE course SET description = $1, name = $2 WHERE id = $3`
+	query := `UPDATE course SET description = $1, name = $2, category_id = $3, price = $4, currency = $5 WHERE id = $6`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, course.Description, course.Name, course.CategoryID, course.Price, course.Currency, course.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetCourseTags replaces the tags of the course
+func (r PostgresCourseRepository) SetCourseTags(ctx context.Context, courseID int, tags []string) error {
+	query := `DELETE FROM course_tag WHERE course_id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, courseID)
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO course_tag (course_id, tag) SELECT $1, UNNEST($2::TEXT[])`
 
-	_, err := r.db.ExecContext(ctx, query, course.Description, course.Name, course.ID)
+	_, err = conn(ctx, r.db).ExecContext(ctx, query, courseID, pq.Array(tags))
 	if err != nil {
 		return err
 	}
@@ -78,10 +175,28 @@ func (r PostgresCourseRepository) UpdateCourse(ctx context.Context, course *mode
 	return nil
 }
 
+// DeleteCourse soft deletes the course, keeping its offerings and enrollment
+// records. Its waitlist is cleared, nobody is promoted into a deleted course.
 func (r PostgresCourseRepository) DeleteCourse(ctx context.Context, id int) error {
-	query := `DELETE FROM course WHERE id = $1`
+	query := `UPDATE course SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrCourseNotFound
+	}
+
+	query = `DELETE FROM waitlist WHERE course_id = $1`
 
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err = conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -89,35 +204,74 @@ func (r PostgresCourseRepository) DeleteCourse(ctx context.Context, id int) erro
 	return nil
 }
 
-func (r PostgresCourseRepository) AddStudentToCourse(ctx context.Context, courseID int, studentID int) error {
-	query := `INSERT INTO enrollment (course_id, student_id) VALUES ($1, $2)`
+func (r PostgresCourseRepository) RestoreCourse(ctx context.Context, id int) error {
+	query := `UPDATE course SET deleted_at = NULL WHERE id = $1`
 
-	_, err := r.db.ExecContext(ctx, query, courseID, studentID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
-		if err.Error() == `pq: duplicate key value violates unique constraint "unique_student_course"` {
-			return repository.ErrStudentAlreadyEnrolled
-		}
 		return err
 	}
 
 	return nil
 }
 
-func (r PostgresCourseRepository) RemoveStudentFromCourse(ctx context.Context, courseID int, studentID int) error {
-	query := `DELETE FROM enrollment WHERE course_id = $1 AND student_id = $2`
+// PurgeCourses permanently removes the courses deleted before deletedBefore
+// along with their enrollments, returning how many were removed. The
+// enrollment history is kept.
+func (r PostgresCourseRepository) PurgeCourses(ctx context.Context, deletedBefore time.Time) (int, error) {
+	query := `
+		DELETE FROM enrollment
+		WHERE course_id IN (SELECT id FROM course WHERE deleted_at < $1)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	query = `DELETE FROM course WHERE deleted_at < $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
 
-	_, err := r.db.ExecContext(ctx, query, courseID, studentID)
+	n, err := result.RowsAffected()
 	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// AddStudentToOffering enrolls the student in the offering with the given
+// status, pending or active. The student can only have one ongoing enrollment
+// per course, whatever the offering.
+func (r PostgresCourseRepository) AddStudentToOffering(ctx context.Context, offering *model.CourseOffering, studentID int, status string) error {
+	column, ok := enrollmentStatusColumns[status]
+	if !ok {
+		return fmt.Errorf("unknown enrollment status %q", status)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO enrollment (course_id, offering_id, student_id, status, %s)
+		VALUES ($1, $2, $3, $4, NOW())`, column)
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, offering.CourseID, offering.ID, studentID, status)
+	if err != nil {
+		if constraint, ok := uniqueViolation(err); ok && constraint == "unique_student_course" {
+			return repository.ErrStudentAlreadyEnrolled
+		}
 		return err
 	}
 
 	return nil
 }
 
-func (r PostgresCourseRepository) HowManyEnrolled(ctx context.Context, courseID int) (int, error) {
-	query := `SELECT COUNT(*) FROM enrollment WHERE course_id = $1`
+// HowManyEnrolled returns how many seats of the offering are taken
+func (r PostgresCourseRepository) HowManyEnrolled(ctx context.Context, offeringID int) (int, error) {
+	query := `SELECT COUNT(*) FROM enrollment WHERE offering_id = $1 AND status IN ('pending', 'active')`
 
-	row := r.db.QueryRowContext(ctx, query, courseID)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, offeringID)
 	var count int
 	if err := row.Scan(&count); err != nil {
 		return 0, err
@@ -125,3 +279,372 @@ func (r PostgresCourseRepository) HowManyEnrolled(ctx context.Context, courseID
 
 	return count, nil
 }
+
+// LockCourse takes a row lock on the course until the surrounding transaction
+// ends, serializing concurrent enrollments in the same course
+func (r PostgresCourseRepository) LockCourse(ctx context.Context, courseID int) error {
+	query := `SELECT id FROM course WHERE id = $1 FOR UPDATE`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, courseID)
+	var id int
+	if err := row.Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrCourseNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresCourseRepository) IsStudentEnrolled(ctx context.Context, courseID, studentID int) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM enrollment
+			WHERE course_id = $1 AND student_id = $2 AND status IN ('pending', 'active')
+		)`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, courseID, studentID)
+	var enrolled bool
+	if err := row.Scan(&enrolled); err != nil {
+		return false, err
+	}
+
+	return enrolled, nil
+}
+
+// GetEnrollment returns the ongoing (pending or active) enrollment of the
+// student in the course
+func (r PostgresCourseRepository) GetEnrollment(ctx context.Context, courseID, studentID int) (*model.Enrollment, error) {
+	query := `SELECT ` + enrollmentColumns + `
+		FROM enrollment
+		WHERE course_id = $1 AND student_id = $2 AND status IN ('pending', 'active')`
+
+	return scanEnrollment(conn(ctx, r.db).QueryRowContext(ctx, query, courseID, studentID))
+}
+
+// GetLastEnrollment returns the most recent enrollment of the student in the
+// course, whatever its status
+func (r PostgresCourseRepository) GetLastEnrollment(ctx context.Context, courseID, studentID int) (*model.Enrollment, error) {
+	query := `SELECT ` + enrollmentColumns + `
+		FROM enrollment
+		WHERE course_id = $1 AND student_id = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`
+
+	return scanEnrollment(conn(ctx, r.db).QueryRowContext(ctx, query, courseID, studentID))
+}
+
+func (r PostgresCourseRepository) GetEnrollmentByID(ctx context.Context, id int64) (*model.Enrollment, error) {
+	query := `SELECT ` + enrollmentColumns + ` FROM enrollment WHERE id = $1`
+
+	return scanEnrollment(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
+const enrollmentColumns = `id, course_id, offering_id, student_id, status, reason, created_at,
+	pending_at, activated_at, completed_at, dropped_at, withdrawn_at`
+
+func scanEnrollment(row *sql.Row) (*model.Enrollment, error) {
+	var e model.Enrollment
+	err := row.Scan(&e.ID, &e.CourseID, &e.OfferingID, &e.StudentID, &e.Status, &e.Reason, &e.CreatedAt,
+		&e.PendingAt, &e.ActivatedAt, &e.CompletedAt, &e.DroppedAt, &e.WithdrawnAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrEnrollmentNotFound
+		}
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+// enrollmentStatusColumns maps each status to the column holding the time the
+// enrollment entered it
+var enrollmentStatusColumns = map[string]string{
+	model.EnrollmentStatusPending:   "pending_at",
+	model.EnrollmentStatusActive:    "activated_at",
+	model.EnrollmentStatusCompleted: "completed_at",
+	model.EnrollmentStatusDropped:   "dropped_at",
+	model.EnrollmentStatusWithdrawn: "withdrawn_at",
+}
+
+// UpdateEnrollmentStatus moves the enrollment to the given status, stamping the
+// time of the transition and storing the reason for it
+func (r PostgresCourseRepository) UpdateEnrollmentStatus(ctx context.Context, enrollment *model.Enrollment) error {
+	column, ok := enrollmentStatusColumns[enrollment.Status]
+	if !ok {
+		return fmt.Errorf("unknown enrollment status %q", enrollment.Status)
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE enrollment SET status = $1, reason = $2, %s = NOW()
+		WHERE id = $3
+		RETURNING %s`, column, column)
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, enrollment.Status, enrollment.Reason, enrollment.ID)
+
+	var changedAt time.Time
+	if err := row.Scan(&changedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrEnrollmentNotFound
+		}
+		return err
+	}
+
+	switch enrollment.Status {
+	case model.EnrollmentStatusPending:
+		enrollment.PendingAt = &changedAt
+	case model.EnrollmentStatusActive:
+		enrollment.ActivatedAt = &changedAt
+	case model.EnrollmentStatusCompleted:
+		enrollment.CompletedAt = &changedAt
+	case model.EnrollmentStatusDropped:
+		enrollment.DroppedAt = &changedAt
+	case model.EnrollmentStatusWithdrawn:
+		enrollment.WithdrawnAt = &changedAt
+	}
+
+	return nil
+}
+
+// LockPrerequisites blocks concurrent changes to the prerequisites graph until
+// the surrounding transaction ends, so two additions can't form a cycle
+// together
+func (r PostgresCourseRepository) LockPrerequisites(ctx context.Context) error {
+	query := `LOCK TABLE course_prerequisite IN SHARE ROW EXCLUSIVE MODE`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresCourseRepository) AddPrerequisite(ctx context.Context, courseID, prerequisiteID int) error {
+	query := `INSERT INTO course_prerequisite (course_id, prerequisite_id) VALUES ($1, $2)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, courseID, prerequisiteID)
+	if err != nil {
+		if constraint, ok := uniqueViolation(err); ok && constraint == "course_prerequisite_pkey" {
+			return repository.ErrPrerequisiteAlreadyAdded
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresCourseRepository) RemovePrerequisite(ctx context.Context, courseID, prerequisiteID int) error {
+	query := `DELETE FROM course_prerequisite WHERE course_id = $1 AND prerequisite_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, courseID, prerequisiteID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrPrerequisiteNotFound
+	}
+
+	return nil
+}
+
+func (r PostgresCourseRepository) GetPrerequisites(ctx context.Context, courseID int) ([]*model.Course, error) {
+	query := `
+		SELECT ` + courseColumns + `
+		FROM course_prerequisite p
+		JOIN course c ON c.id = p.prerequisite_id
+		WHERE p.course_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.id`
+
+	return r.queryCourses(ctx, query, courseID)
+}
+
+// RequiresTransitively reports whether courseID has prerequisiteID as a
+// prerequisite, directly or through other prerequisites. Deleted courses are
+// no longer required.
+func (r PostgresCourseRepository) RequiresTransitively(ctx context.Context, courseID, prerequisiteID int) (bool, error) {
+	query := `
+		WITH RECURSIVE required(id) AS (
+			SELECT p.prerequisite_id
+			FROM course_prerequisite p
+			JOIN course c ON c.id = p.prerequisite_id
+			WHERE p.course_id = $1 AND c.deleted_at IS NULL
+			UNION
+			SELECT p.prerequisite_id
+			FROM course_prerequisite p
+			JOIN required r ON p.course_id = r.id
+			JOIN course c ON c.id = p.prerequisite_id
+			WHERE c.deleted_at IS NULL
+		)
+		SELECT EXISTS (SELECT 1 FROM required WHERE id = $2)`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, courseID, prerequisiteID)
+	var requires bool
+	if err := row.Scan(&requires); err != nil {
+		return false, err
+	}
+
+	return requires, nil
+}
+
+// GetMissingPrerequisites returns the prerequisites of the course the student
+// hasn't completed yet. Deleted prerequisites are not required anymore.
+func (r PostgresCourseRepository) GetMissingPrerequisites(ctx context.Context, courseID, studentID int) ([]*model.Course, error) {
+	query := `
+		SELECT ` + courseColumns + `
+		FROM course_prerequisite p
+		JOIN course c ON c.id = p.prerequisite_id
+		WHERE p.course_id = $1 AND c.deleted_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM enrollment e
+			WHERE e.course_id = p.prerequisite_id AND e.student_id = $2 AND e.status = 'completed'
+		)
+		ORDER BY c.id`
+
+	return r.queryCourses(ctx, query, courseID, studentID)
+}
+
+// GetCourseStudents returns the students currently enrolled in the course,
+// optionally filtered by a search on the student name
+func (r PostgresCourseRepository) GetCourseStudents(ctx context.Context, courseID int, search string) ([]*model.Student, error) {
+	return r.queryRoster(ctx, "e.course_id", courseID, search)
+}
+
+// GetOfferingStudents returns the students currently enrolled in the offering,
+// optionally filtered by a search on the student name
+func (r PostgresCourseRepository) GetOfferingStudents(ctx context.Context, offeringID int, search string) ([]*model.Student, error) {
+	return r.queryRoster(ctx, "e.offering_id", offeringID, search)
+}
+
+// queryRoster returns the students with an ongoing enrollment whose column
+// matches id
+func (r PostgresCourseRepository) queryRoster(ctx context.Context, column string, id int, search string) ([]*model.Student, error) {
+	query := `
+		SELECT ` + studentColumns + `
+		FROM enrollment e
+		JOIN student s ON s.id = e.student_id
+		WHERE ` + column + ` = $1 AND e.status IN ('pending', 'active')`
+	args := []any{id}
+
+	condition, args := searchCondition("s.name", search, args)
+	if condition != "" {
+		query += ` AND ` + condition
+	}
+
+	query += ` ORDER BY s.id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []*model.Student
+
+	for rows.Next() {
+		student, err := scanStudent(rows)
+		if err != nil {
+			return nil, err
+		}
+		students = append(students, student)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return students, nil
+}
+
+func (r PostgresCourseRepository) queryCourses(ctx context.Context, query string, args ...any) ([]*model.Course, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []*model.Course
+
+	for rows.Next() {
+		course, err := scanCourse(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		courses = append(courses, course)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return courses, nil
+}
+
+func (r PostgresCourseRepository) AddEnrollmentEvent(ctx context.Context, event *model.EnrollmentEvent) error {
+	query := `
+		INSERT INTO enrollment_history (enrollment_id, course_id, student_id, event, status, reason, actor)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, event.EnrollmentID, event.CourseID, event.StudentID,
+		event.Event, event.Status, event.Reason, event.Actor)
+	err := row.Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetCourseStudentsAsOf rebuilds the roster of the course at the given time
+// from the enrollment history, optionally filtered by a search on the student
+// name
+func (r PostgresCourseRepository) GetCourseStudentsAsOf(ctx context.Context, courseID int, asOf time.Time, search string) ([]*model.Student, error) {
+	query := `
+		SELECT ` + studentColumns + `
+		FROM (
+			SELECT DISTINCT ON (enrollment_id) student_id, status
+			FROM enrollment_history
+			WHERE course_id = $1 AND created_at <= $2
+			ORDER BY enrollment_id, created_at DESC, id DESC
+		) h
+		JOIN student s ON s.id = h.student_id
+		WHERE h.status IN ('pending', 'active')`
+	args := []any{courseID, asOf}
+
+	condition, args := searchCondition("s.name", search, args)
+	if condition != "" {
+		query += ` AND ` + condition
+	}
+
+	query += ` ORDER BY s.id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []*model.Student
+
+	for rows.Next() {
+		student, err := scanStudent(rows)
+		if err != nil {
+			return nil, err
+		}
+		students = append(students, student)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return students, nil
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation returns the name of the unique constraint violated by err,
+// if that is why it failed
+func uniqueViolation(err error) (string, bool) {
+	return violation(err, "unique_violation")
+}
+
+// checkViolation returns the name of the check constraint violated by err, if
+// that is why it failed
+func checkViolation(err error) (string, bool) {
+	return violation(err, "check_violation")
+}
+
+// foreignKeyViolation returns the name of the foreign key violated by err, if
+// that is why it failed
+func foreignKeyViolation(err error) (string, bool) {
+	return violation(err, "foreign_key_violation")
+}
+
+// violation returns the constraint named by err when it is a postgres error
+// with the given condition name
+func violation(err error, condition string) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == condition {
+		return pqErr.Constraint, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type PostgresGradeRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresGradeRepository(db *sql.DB) *PostgresGradeRepository {
+	return &PostgresGradeRepository{db: db}
+}
+
+func (r PostgresGradeRepository) GetGrade(ctx context.Context, enrollmentID int64) (*model.Grade, error) {
+	query := `
+		SELECT id, enrollment_id, value, finalized, finalized_at, created_at, updated_at
+		FROM grade
+		WHERE enrollment_id = $1`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, enrollmentID)
+
+	var grade model.Grade
+	err := row.Scan(&grade.ID, &grade.EnrollmentID, &grade.Value, &grade.Finalized, &grade.FinalizedAt,
+		&grade.CreatedAt, &grade.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrGradeNotFound
+		}
+		return nil, err
+	}
+
+	return &grade, nil
+}
+
+func (r PostgresGradeRepository) SaveGrade(ctx context.Context, grade *model.Grade) error {
+	query := `
+		INSERT INTO grade (enrollment_id, value, finalized, finalized_at)
+		VALUES ($1, $2, $3, CASE WHEN $3 THEN NOW() END)
+		RETURNING id, finalized_at, created_at, updated_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, grade.EnrollmentID, grade.Value, grade.Finalized)
+	err := row.Scan(&grade.ID, &grade.FinalizedAt, &grade.CreatedAt, &grade.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateGrade stores the new value of the grade. finalized_at keeps the time
+// the grade was first finalized.
+func (r PostgresGradeRepository) UpdateGrade(ctx context.Context, grade *model.Grade) error {
+	query := `
+		UPDATE grade
+		SET value = $1,
+			finalized = $2,
+			finalized_at = CASE WHEN $2 THEN COALESCE(finalized_at, NOW()) END,
+			updated_at = NOW()
+		WHERE id = $3
+		RETURNING finalized_at, updated_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, grade.Value, grade.Finalized, grade.ID)
+	err := row.Scan(&grade.FinalizedAt, &grade.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrGradeNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresGradeRepository) AddGradeCorrection(ctx context.Context, correction *model.GradeCorrection) error {
+	query := `
+		INSERT INTO grade_correction (grade_id, old_value, new_value, reason, actor)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, correction.GradeID, correction.OldValue, correction.NewValue,
+		correction.Reason, correction.Actor)
+	err := row.Scan(&correction.ID, &correction.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetTranscript returns the completed courses of the student, in the order
+// they were completed, along with their grades
+func (r PostgresGradeRepository) GetTranscript(ctx context.Context, studentID int) ([]*model.TranscriptEntry, error) {
+	query := `
+		SELECT c.id, c.name, e.completed_at, g.value, COALESCE(g.finalized, FALSE)
+		FROM enrollment e
+		JOIN course c ON c.id = e.course_id
+		LEFT JOIN grade g ON g.enrollment_id = e.id
+		WHERE e.student_id = $1 AND e.status = 'completed'
+		ORDER BY e.completed_at, e.id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*model.TranscriptEntry
+
+	for rows.Next() {
+		var entry model.TranscriptEntry
+		if err := rows.Scan(&entry.CourseID, &entry.CourseName, &entry.CompletedAt, &entry.Grade, &entry.Finalized); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+	"github.com/lib/pq"
+)
+
+type PostgresInstructorRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresInstructorRepository(db *sql.DB) *PostgresInstructorRepository {
+	return &PostgresInstructorRepository{db: db}
+}
+
+func (r PostgresInstructorRepository) Save(ctx context.Context, instructor *model.Instructor) error {
+	query := `INSERT INTO instructor (name) VALUES ($1) RETURNING id`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, instructor.Name)
+	err := row.Scan(&instructor.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresInstructorRepository) GetInstructors(ctx context.Context, search string) ([]*model.Instructor, error) {
+	query := `SELECT id, name FROM instructor`
+	args := []any{}
+
+	condition, args := searchCondition("name", search, args)
+	if condition != "" {
+		query += ` WHERE ` + condition
+	}
+
+	query += ` ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var instructors []*model.Instructor
+
+	for rows.Next() {
+		var instructor model.Instructor
+		if err := rows.Scan(&instructor.ID, &instructor.Name); err != nil {
+			return nil, err
+		}
+		instructors = append(instructors, &instructor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return instructors, nil
+}
+
+func (r PostgresInstructorRepository) GetInstructor(ctx context.Context, id int) (*model.Instructor, error) {
+	query := `SELECT id, name FROM instructor WHERE id = $1`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id)
+
+	var instructor model.Instructor
+	if err := row.Scan(&instructor.ID, &instructor.Name); err != nil {
+		return nil, err
+	}
+
+	return &instructor, nil
+}
+
+func (r PostgresInstructorRepository) UpdateInstructor(ctx context.Context, instructor *model.Instructor) error {
+	query := `UPDATE instructor SET name = $1 WHERE id = $2`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, instructor.Name, instructor.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteInstructor removes the instructor, their course assignments go along
+// with them
+func (r PostgresInstructorRepository) DeleteInstructor(ctx context.Context, id int) error {
+	query := `DELETE FROM instructor WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrInstructorNotFound
+	}
+
+	return nil
+}
+
+// AssignCourse assigns the instructor to the course, or changes their role if
+// they are already assigned to it
+func (r PostgresInstructorRepository) AssignCourse(ctx context.Context, courseID, instructorID int, role string) error {
+	query := `
+		INSERT INTO course_instructor (course_id, instructor_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (course_id, instructor_id) DO UPDATE SET role = EXCLUDED.role`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, courseID, instructorID, role)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresInstructorRepository) UnassignCourse(ctx context.Context, courseID, instructorID int) error {
+	query := `DELETE FROM course_instructor WHERE course_id = $1 AND instructor_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, courseID, instructorID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrInstructorNotAssigned
+	}
+
+	return nil
+}
+
+// GetInstructorCourses returns the courses the instructor is assigned to
+func (r PostgresInstructorRepository) GetInstructorCourses(ctx context.Context, instructorID int) ([]*model.InstructorCourse, error) {
+	query := `
+		SELECT ` + courseColumns + `, ci.role, ci.assigned_at
+		FROM course_instructor ci
+		JOIN course c ON c.id = ci.course_id
+		WHERE ci.instructor_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, instructorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []*model.InstructorCourse
+
+	for rows.Next() {
+		course := model.InstructorCourse{Course: &model.Course{}}
+		err := rows.Scan(&course.ID, &course.Description, &course.Name, &course.CategoryID, pq.Array(&course.Tags), &course.Price, &course.Currency,
+			&course.Status, &course.PublishedAt, &course.ArchivedAt, &course.DeletedAt, &course.Role, &course.AssignedAt)
+		if err != nil {
+			return nil, err
+		}
+		courses = append(courses, &course)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return courses, nil
+}
+
+// GetCourseInstructors returns the instructors assigned to the course, leads
+// first
+func (r PostgresInstructorRepository) GetCourseInstructors(ctx context.Context, courseID int) ([]*model.CourseInstructor, error) {
+	query := `
+		SELECT i.id, i.name, ci.role, ci.assigned_at
+		FROM course_instructor ci
+		JOIN instructor i ON i.id = ci.instructor_id
+		WHERE ci.course_id = $1
+		ORDER BY ci.role = 'lead' DESC, i.id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var instructors []*model.CourseInstructor
+
+	for rows.Next() {
+		instructor := model.CourseInstructor{Instructor: &model.Instructor{}}
+		if err := rows.Scan(&instructor.ID, &instructor.Name, &instructor.Role, &instructor.AssignedAt); err != nil {
+			return nil, err
+		}
+		instructors = append(instructors, &instructor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return instructors, nil
+}
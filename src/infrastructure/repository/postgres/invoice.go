@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type PostgresInvoiceRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresInvoiceRepository(db *sql.DB) *PostgresInvoiceRepository {
+	return &PostgresInvoiceRepository{db: db}
+}
+
+const invoiceColumns = `id, enrollment_id, student_id, course_id, amount, discount, coupon_id, scholarship_id, currency,
+	status, gateway, gateway_reference, payment_url, created_at, expires_at, paid_at, expired_at, canceled_at, refunded_at`
+
+func scanInvoice(scanner interface{ Scan(...any) error }) (*model.Invoice, error) {
+	var i model.Invoice
+	err := scanner.Scan(&i.ID, &i.EnrollmentID, &i.StudentID, &i.CourseID, &i.Amount, &i.Discount, &i.CouponID, &i.ScholarshipID, &i.Currency,
+		&i.Status, &i.Gateway, &i.Reference, &i.PaymentURL, &i.CreatedAt, &i.ExpiresAt, &i.PaidAt, &i.ExpiredAt, &i.CanceledAt, &i.RefundedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+func (r PostgresInvoiceRepository) SaveInvoice(ctx context.Context, invoice *model.Invoice) error {
+	query := `
+		INSERT INTO invoice (enrollment_id, student_id, course_id, amount, discount, coupon_id, scholarship_id, currency, gateway, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, status, created_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, invoice.EnrollmentID, invoice.StudentID, invoice.CourseID,
+		invoice.Amount, invoice.Discount, invoice.CouponID, invoice.ScholarshipID, invoice.Currency, invoice.Gateway, invoice.ExpiresAt)
+	err := row.Scan(&invoice.ID, &invoice.Status, &invoice.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetInvoiceCharge stores the charge the gateway created for the invoice
+func (r PostgresInvoiceRepository) SetInvoiceCharge(ctx context.Context, invoice *model.Invoice) error {
+	query := `UPDATE invoice SET gateway_reference = $1, payment_url = $2 WHERE id = $3`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, invoice.Reference, invoice.PaymentURL, invoice.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresInvoiceRepository) GetInvoice(ctx context.Context, id int) (*model.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + ` FROM invoice WHERE id = $1`
+
+	return scanInvoice(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
+func (r PostgresInvoiceRepository) GetInvoiceByReference(ctx context.Context, gateway, reference string) (*model.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + ` FROM invoice WHERE gateway = $1 AND gateway_reference = $2`
+
+	return scanInvoice(conn(ctx, r.db).QueryRowContext(ctx, query, gateway, reference))
+}
+
+// GetOpenInvoice returns the invoice of the enrollment still waiting for
+// payment
+func (r PostgresInvoiceRepository) GetOpenInvoice(ctx context.Context, enrollmentID int64) (*model.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + ` FROM invoice WHERE enrollment_id = $1 AND status = 'open'`
+
+	return scanInvoice(conn(ctx, r.db).QueryRowContext(ctx, query, enrollmentID))
+}
+
+func (r PostgresInvoiceRepository) GetStudentInvoices(ctx context.Context, studentID int) ([]*model.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + ` FROM invoice WHERE student_id = $1 ORDER BY id DESC`
+
+	return r.queryInvoices(ctx, query, studentID)
+}
+
+// GetExpiredInvoices returns the open invoices that were due before now
+func (r PostgresInvoiceRepository) GetExpiredInvoices(ctx context.Context, now time.Time) ([]*model.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + ` FROM invoice WHERE status = 'open' AND expires_at <= $1 ORDER BY id`
+
+	return r.queryInvoices(ctx, query, now)
+}
+
+func (r PostgresInvoiceRepository) queryInvoices(ctx context.Context, query string, args ...any) ([]*model.Invoice, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []*model.Invoice
+
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invoices, nil
+}
+
+// LockInvoice takes a row lock on the invoice until the surrounding
+// transaction ends, so a payment and its expiration can't both go through
+func (r PostgresInvoiceRepository) LockInvoice(ctx context.Context, id int64) error {
+	query := `SELECT id FROM invoice WHERE id = $1 FOR UPDATE`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id)
+	var invoiceID int64
+	if err := row.Scan(&invoiceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrInvoiceNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// invoiceStatusColumns maps each final status to the column holding the time
+// the invoice entered it
+var invoiceStatusColumns = map[string]string{
+	model.InvoiceStatusPaid:     "paid_at",
+	model.InvoiceStatusExpired:  "expired_at",
+	model.InvoiceStatusCanceled: "canceled_at",
+	model.InvoiceStatusPaidLate: "paid_at",
+	model.InvoiceStatusRefunded: "refunded_at",
+}
+
+// UpdateInvoiceStatus moves the invoice to the given status, stamping the time
+// of the transition
+func (r PostgresInvoiceRepository) UpdateInvoiceStatus(ctx context.Context, invoice *model.Invoice) error {
+	column, ok := invoiceStatusColumns[invoice.Status]
+	if !ok {
+		return fmt.Errorf("unknown invoice status %q", invoice.Status)
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE invoice SET status = $1, %s = NOW()
+		WHERE id = $2
+		RETURNING %s`, column, column)
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, invoice.Status, invoice.ID)
+
+	var changedAt time.Time
+	if err := row.Scan(&changedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrInvoiceNotFound
+		}
+		return err
+	}
+
+	switch invoice.Status {
+	case model.InvoiceStatusPaid, model.InvoiceStatusPaidLate:
+		invoice.PaidAt = &changedAt
+	case model.InvoiceStatusExpired:
+		invoice.ExpiredAt = &changedAt
+	case model.InvoiceStatusCanceled:
+		invoice.CanceledAt = &changedAt
+	case model.InvoiceStatusRefunded:
+		invoice.RefundedAt = &changedAt
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+const offeringColumns = `id, course_id, name, starts_on, ends_on, capacity, enrollment_opens_at, enrollment_closes_at, is_default, created_at`
+
+func scanOffering(scanner interface{ Scan(...any) error }) (*model.CourseOffering, error) {
+	var o model.CourseOffering
+	err := scanner.Scan(&o.ID, &o.CourseID, &o.Name, &o.StartsOn, &o.EndsOn, &o.Capacity,
+		&o.EnrollmentOpensAt, &o.EnrollmentClosesAt, &o.IsDefault, &o.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+func (r PostgresCourseRepository) SaveOffering(ctx context.Context, offering *model.CourseOffering) error {
+	query := `
+		INSERT INTO course_offering (course_id, name, starts_on, ends_on, capacity, enrollment_opens_at, enrollment_closes_at, is_default)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, offering.CourseID, offering.Name, offering.StartsOn, offering.EndsOn,
+		offering.Capacity, offering.EnrollmentOpensAt, offering.EnrollmentClosesAt, offering.IsDefault)
+	err := row.Scan(&offering.ID, &offering.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresCourseRepository) GetOffering(ctx context.Context, id int) (*model.CourseOffering, error) {
+	query := `SELECT ` + offeringColumns + ` FROM course_offering WHERE id = $1`
+
+	offering, err := scanOffering(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrOfferingNotFound
+		}
+		return nil, err
+	}
+
+	return offering, nil
+}
+
+// GetDefaultOffering returns the offering taking the enrollments made through
+// the course. Every course has one, so ErrCourseNotFound is returned when it is
+// missing.
+func (r PostgresCourseRepository) GetDefaultOffering(ctx context.Context, courseID int) (*model.CourseOffering, error) {
+	query := `SELECT ` + offeringColumns + ` FROM course_offering WHERE course_id = $1 AND is_default`
+
+	offering, err := scanOffering(conn(ctx, r.db).QueryRowContext(ctx, query, courseID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrCourseNotFound
+		}
+		return nil, err
+	}
+
+	return offering, nil
+}
+
+// GetOfferings returns the offerings of the course, the default one first and
+// the others in the order they start
+func (r PostgresCourseRepository) GetOfferings(ctx context.Context, courseID int) ([]*model.CourseOffering, error) {
+	query := `
+		SELECT ` + offeringColumns + `
+		FROM course_offering
+		WHERE course_id = $1
+		ORDER BY is_default DESC, starts_on NULLS LAST, id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var offerings []*model.CourseOffering
+
+	for rows.Next() {
+		offering, err := scanOffering(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		offerings = append(offerings, offering)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return offerings, nil
+}
+
+func (r PostgresCourseRepository) UpdateOffering(ctx context.Context, offering *model.CourseOffering) error {
+	query := `
+		UPDATE course_offering
+		SET name = $1, starts_on = $2, ends_on = $3, capacity = $4, enrollment_opens_at = $5, enrollment_closes_at = $6
+		WHERE id = $7`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, offering.Name, offering.StartsOn, offering.EndsOn, offering.Capacity,
+		offering.EnrollmentOpensAt, offering.EnrollmentClosesAt, offering.ID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrOfferingNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+const paymentTaskColumns = `id, invoice_id, kind, attempts, last_error, created_at, processed_at`
+
+func scanPaymentTask(scanner interface{ Scan(...any) error }) (*model.PaymentTask, error) {
+	var t model.PaymentTask
+	err := scanner.Scan(&t.ID, &t.InvoiceID, &t.Kind, &t.Attempts, &t.LastError, &t.CreatedAt, &t.ProcessedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrPaymentTaskNotFound
+		}
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (r PostgresInvoiceRepository) SavePaymentTask(ctx context.Context, task *model.PaymentTask) error {
+	query := `INSERT INTO payment_task (invoice_id, kind) VALUES ($1, $2) RETURNING id, created_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, task.InvoiceID, task.Kind)
+	err := row.Scan(&task.ID, &task.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetPendingPaymentTasks returns the tasks not processed yet, oldest first
+func (r PostgresInvoiceRepository) GetPendingPaymentTasks(ctx context.Context) ([]*model.PaymentTask, error) {
+	query := `SELECT ` + paymentTaskColumns + ` FROM payment_task WHERE processed_at IS NULL ORDER BY id`
+
+	return r.queryPaymentTasks(ctx, query)
+}
+
+// GetInvoicePendingPaymentTasks returns the tasks of the invoice not
+// processed yet, oldest first
+func (r PostgresInvoiceRepository) GetInvoicePendingPaymentTasks(ctx context.Context, invoiceID int64) ([]*model.PaymentTask, error) {
+	query := `SELECT ` + paymentTaskColumns + ` FROM payment_task WHERE invoice_id = $1 AND processed_at IS NULL ORDER BY id`
+
+	return r.queryPaymentTasks(ctx, query, invoiceID)
+}
+
+func (r PostgresInvoiceRepository) queryPaymentTasks(ctx context.Context, query string, args ...any) ([]*model.PaymentTask, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*model.PaymentTask
+
+	for rows.Next() {
+		task, err := scanPaymentTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// ClaimPaymentTask locks the task until the surrounding transaction ends, so
+// no one else runs it meanwhile. A task is only claimed once the earlier tasks
+// of its invoice are processed, so a charge is never canceled before it is
+// created. ErrPaymentTaskNotFound is returned when the task is processed,
+// claimed by someone else or waiting for an earlier one.
+func (r PostgresInvoiceRepository) ClaimPaymentTask(ctx context.Context, id int64) (*model.PaymentTask, error) {
+	query := `
+		SELECT ` + paymentTaskColumns + `
+		FROM payment_task t
+		WHERE t.id = $1 AND t.processed_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM payment_task earlier
+			WHERE earlier.invoice_id = t.invoice_id AND earlier.id < t.id AND earlier.processed_at IS NULL
+		)
+		FOR UPDATE SKIP LOCKED`
+
+	return scanPaymentTask(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
+// FinishPaymentTask marks the task as processed
+func (r PostgresInvoiceRepository) FinishPaymentTask(ctx context.Context, task *model.PaymentTask) error {
+	query := `UPDATE payment_task SET attempts = attempts + 1, processed_at = NOW() WHERE id = $1 RETURNING attempts, processed_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, task.ID)
+	return row.Scan(&task.Attempts, &task.ProcessedAt)
+}
+
+// FailPaymentTask records a failed attempt at the task, which stays pending
+func (r PostgresInvoiceRepository) FailPaymentTask(ctx context.Context, task *model.PaymentTask, reason string) error {
+	query := `UPDATE payment_task SET attempts = attempts + 1, last_error = $1 WHERE id = $2 RETURNING attempts`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, reason, task.ID)
+	if err := row.Scan(&task.Attempts); err != nil {
+		return err
+	}
+
+	task.LastError = &reason
+	return nil
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type PostgresRoomRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRoomRepository(db *sql.DB) *PostgresRoomRepository {
+	return &PostgresRoomRepository{db: db}
+}
+
+func (r PostgresRoomRepository) Save(ctx context.Context, room *model.Room) error {
+	query := `INSERT INTO room (name, seats) VALUES ($1, $2) RETURNING id`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, room.Name, room.Seats)
+	err := row.Scan(&room.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresRoomRepository) GetRooms(ctx context.Context) ([]*model.Room, error) {
+	query := `SELECT id, name, seats FROM room ORDER BY id`
+
+	return r.queryRooms(ctx, query)
+}
+
+func (r PostgresRoomRepository) queryRooms(ctx context.Context, query string, args ...any) ([]*model.Room, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []*model.Room
+
+	for rows.Next() {
+		var room model.Room
+		if err := rows.Scan(&room.ID, &room.Name, &room.Seats); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, &room)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+func (r PostgresRoomRepository) GetRoom(ctx context.Context, id int) (*model.Room, error) {
+	query := `SELECT id, name, seats FROM room WHERE id = $1`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id)
+
+	var room model.Room
+	if err := row.Scan(&room.ID, &room.Name, &room.Seats); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrRoomNotFound
+		}
+		return nil, err
+	}
+
+	return &room, nil
+}
+
+func (r PostgresRoomRepository) UpdateRoom(ctx context.Context, room *model.Room) error {
+	query := `UPDATE room SET name = $1, seats = $2 WHERE id = $3`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, room.Name, room.Seats, room.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteRoom removes the room, the slots booked in it are released
+func (r PostgresRoomRepository) DeleteRoom(ctx context.Context, id int) error {
+	query := `DELETE FROM room WHERE id = $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrRoomNotFound
+	}
+
+	return nil
+}
+
+// LockRoom takes a row lock on the room until the surrounding transaction ends,
+// serializing concurrent bookings of the same room
+func (r PostgresRoomRepository) LockRoom(ctx context.Context, id int) error {
+	query := `SELECT id FROM room WHERE id = $1 FOR UPDATE`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, id)
+	var roomID int
+	if err := row.Scan(&roomID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrRoomNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetRoomBookings returns the slots booked in the room in weekly order
+func (r PostgresRoomRepository) GetRoomBookings(ctx context.Context, roomID int) ([]*model.RoomBooking, error) {
+	query := `
+		SELECT ` + slotColumns + `, c.name
+		FROM course_slot s
+		JOIN course c ON c.id = s.course_id
+		WHERE s.room_id = $1
+		ORDER BY s.weekday, s.start_time`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookings []*model.RoomBooking
+
+	for rows.Next() {
+		booking := model.RoomBooking{CourseSlot: &model.CourseSlot{}}
+		err := rows.Scan(&booking.ID, &booking.CourseID, &booking.Weekday, &booking.StartTime, &booking.EndTime, &booking.RoomID, &booking.CourseName)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, &booking)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// GetCourseRooms returns the rooms booked for any slot of the course. Their
+// seats can't change until the surrounding transaction ends.
+func (r PostgresRoomRepository) GetCourseRooms(ctx context.Context, courseID int) ([]*model.Room, error) {
+	query := `
+		SELECT id, name, seats FROM room
+		WHERE id IN (SELECT room_id FROM course_slot WHERE course_id = $1)
+		ORDER BY id
+		FOR SHARE`
+
+	return r.queryRooms(ctx, query, courseID)
+}
+
+// MaxBookedCapacity returns the largest capacity among the offerings of the
+// courses booked in the room, or zero if the room isn't booked
+func (r PostgresRoomRepository) MaxBookedCapacity(ctx context.Context, roomID int) (int, error) {
+	query := `
+		SELECT COALESCE(MAX(o.capacity), 0)
+		FROM course_offering o
+		WHERE o.course_id IN (SELECT course_id FROM course_slot WHERE room_id = $1)`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, roomID)
+
+	var capacity int
+	if err := row.Scan(&capacity); err != nil {
+		return 0, err
+	}
+
+	return capacity, nil
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type PostgresScholarshipRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresScholarshipRepository(db *sql.DB) *PostgresScholarshipRepository {
+	return &PostgresScholarshipRepository{db: db}
+}
+
+const scholarshipColumns = `id, student_id, course_id, percent, reason, valid_from, valid_until, created_at`
+
+func scanScholarship(scanner interface{ Scan(...any) error }) (*model.Scholarship, error) {
+	var s model.Scholarship
+	err := scanner.Scan(&s.ID, &s.StudentID, &s.CourseID, &s.Percent, &s.Reason, &s.ValidFrom, &s.ValidUntil, &s.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrScholarshipNotFound
+		}
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (r PostgresScholarshipRepository) SaveScholarship(ctx context.Context, scholarship *model.Scholarship) error {
+	query := `
+		INSERT INTO scholarship (student_id, course_id, percent, reason, valid_from, valid_until)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, scholarship.StudentID, scholarship.CourseID, scholarship.Percent,
+		scholarship.Reason, scholarship.ValidFrom, scholarship.ValidUntil)
+	err := row.Scan(&scholarship.ID, &scholarship.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresScholarshipRepository) GetStudentScholarships(ctx context.Context, studentID int) ([]*model.Scholarship, error) {
+	query := `SELECT ` + scholarshipColumns + ` FROM scholarship WHERE student_id = $1 ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scholarships []*model.Scholarship
+
+	for rows.Next() {
+		scholarship, err := scanScholarship(rows)
+		if err != nil {
+			return nil, err
+		}
+		scholarships = append(scholarships, scholarship)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return scholarships, nil
+}
+
+// GetBestScholarship returns the scholarship of the student giving the largest
+// discount on the course at the given time, or nil when there is none
+func (r PostgresScholarshipRepository) GetBestScholarship(ctx context.Context, studentID, courseID int, now time.Time) (*model.Scholarship, error) {
+	query := `SELECT ` + scholarshipColumns + `
+		FROM scholarship
+		WHERE student_id = $1
+			AND (course_id IS NULL OR course_id = $2)
+			AND (valid_from IS NULL OR valid_from <= $3)
+			AND (valid_until IS NULL OR valid_until > $3)
+		ORDER BY percent DESC, id
+		LIMIT 1`
+
+	scholarship, err := scanScholarship(conn(ctx, r.db).QueryRowContext(ctx, query, studentID, courseID, now))
+	if err == repository.ErrScholarshipNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return scholarship, nil
+}
+
+func (r PostgresScholarshipRepository) DeleteScholarship(ctx context.Context, studentID, id int) error {
+	query := `DELETE FROM scholarship WHERE id = $1 AND student_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, studentID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrScholarshipNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+)
+
+// searchCondition builds a condition matching every word of search against
+// column, ignoring case and accents. args holds the arguments already bound in
+// the query, the search terms are appended to it.
+func searchCondition(column, search string, args []any) (string, []any) {
+	conditions := []string{}
+
+	for _, term := range strings.Fields(search) {
+		args = append(args, "%"+term+"%")
+		conditions = append(conditions, fmt.Sprintf("LOWER(UNACCENT(%s)) ILIKE LOWER(UNACCENT($%d))", column, len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+// slotColumns selects a course_slot row with its times formatted as "15:04"
+const slotColumns = `s.id, s.course_id, s.weekday, TO_CHAR(s.start_time, 'HH24:MI'), TO_CHAR(s.end_time, 'HH24:MI'), s.room_id`
+
+func scanSlot(scanner interface{ Scan(...any) error }) (*model.CourseSlot, error) {
+	var slot model.CourseSlot
+	err := scanner.Scan(&slot.ID, &slot.CourseID, &slot.Weekday, &slot.StartTime, &slot.EndTime, &slot.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &slot, nil
+}
+
+func (r PostgresCourseRepository) AddSlot(ctx context.Context, slot *model.CourseSlot) error {
+	query := `INSERT INTO course_slot (course_id, weekday, start_time, end_time, room_id) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, slot.CourseID, slot.Weekday, slot.StartTime, slot.EndTime, slot.RoomID)
+	err := row.Scan(&slot.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresCourseRepository) RemoveSlot(ctx context.Context, courseID, slotID int) error {
+	query := `DELETE FROM course_slot WHERE course_id = $1 AND id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, courseID, slotID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrSlotNotFound
+	}
+
+	return nil
+}
+
+func (r PostgresCourseRepository) GetSlot(ctx context.Context, courseID, slotID int) (*model.CourseSlot, error) {
+	query := `SELECT ` + slotColumns + ` FROM course_slot s WHERE s.course_id = $1 AND s.id = $2`
+
+	slot, err := scanSlot(conn(ctx, r.db).QueryRowContext(ctx, query, courseID, slotID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrSlotNotFound
+		}
+		return nil, err
+	}
+
+	return slot, nil
+}
+
+// SetSlotRoom books the room for the slot. A nil roomID releases the room.
+func (r PostgresCourseRepository) SetSlotRoom(ctx context.Context, courseID, slotID int, roomID *int) error {
+	query := `UPDATE course_slot SET room_id = $1 WHERE course_id = $2 AND id = $3`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, roomID, courseID, slotID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrSlotNotFound
+	}
+
+	return nil
+}
+
+func (r PostgresCourseRepository) GetSlots(ctx context.Context, courseID int) ([]*model.CourseSlot, error) {
+	query := `SELECT ` + slotColumns + ` FROM course_slot s WHERE s.course_id = $1 ORDER BY s.weekday, s.start_time`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slots []*model.CourseSlot
+
+	for rows.Next() {
+		slot, err := scanSlot(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		slots = append(slots, slot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
+
+// FindScheduleConflict returns a slot of another course the student is
+// enrolled in that overlaps a slot of the given course, or nil if there is
+// none
+func (r PostgresCourseRepository) FindScheduleConflict(ctx context.Context, courseID, studentID int) (*model.CourseSlot, error) {
+	query := `
+		SELECT ` + slotColumns + `
+		FROM course_slot target
+		JOIN course_slot s ON s.weekday = target.weekday
+			AND s.start_time < target.end_time
+			AND target.start_time < s.end_time
+		JOIN enrollment e ON e.course_id = s.course_id
+		WHERE target.course_id = $1
+			AND s.course_id <> $1
+			AND e.student_id = $2
+			AND e.status IN ('pending', 'active')
+		ORDER BY s.weekday, s.start_time
+		LIMIT 1`
+
+	slot, err := scanSlot(conn(ctx, r.db).QueryRowContext(ctx, query, courseID, studentID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return slot, nil
+}
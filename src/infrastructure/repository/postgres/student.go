@@ -3,10 +3,12 @@ package postgres
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 	"strings"
+	"time"
 
 	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
 )
 
 type PostgresStudentRepository struct {
@@ -17,37 +19,116 @@ func NewPostgresStudentRepository(db *sql.DB) *PostgresStudentRepository {
 	return &PostgresStudentRepository{db: db}
 }
 
+// studentConflict tells which unique field of the student err is about, if
+// any
+func studentConflict(err error) error {
+	constraint, ok := uniqueViolation(err)
+	if !ok {
+		return err
+	}
+
+	switch constraint {
+	case "student_email_key":
+		return repository.ErrStudentEmailTaken
+	case "student_cpf_key":
+		return repository.ErrStudentCPFTaken
+	default:
+		return err
+	}
+}
+
 func (r PostgresStudentRepository) Save(ctx context.Context, student *model.Student) error {
-	query := `INSERT INTO student (name) VALUES ($1) RETURNING id`
+	query := `INSERT INTO student (name, email, phone, birth_date, cpf) VALUES ($1, $2, $3, $4, $5) RETURNING id`
 
-	row := r.db.QueryRow(query, student.Name)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, student.Name, student.Email, student.Phone, student.BirthDate, student.CPF)
 	err := row.Scan(&student.ID)
 	if err != nil {
-		return err
+		return studentConflict(err)
 	}
 
 	return nil
 }
 
-func (r PostgresStudentRepository) GetStudents(ctx context.Context, search string) ([]*model.Student, error) {
-	query := `SELECT id, name FROM student`
+// studentColumns selects a student row aliased as s
+const studentColumns = `s.id, s.name, s.email, s.phone, s.birth_date, s.cpf, s.max_courses, s.deleted_at`
+
+func scanStudent(scanner interface{ Scan(...any) error }) (*model.Student, error) {
+	var student model.Student
+	err := scanner.Scan(&student.ID, &student.Name, &student.Email, &student.Phone, &student.BirthDate, &student.CPF,
+		&student.MaxCourses, &student.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &student, nil
+}
+
+// GetStudents returns the students matching the search on their name, email
+// or CPF, leaving the deleted ones out unless includeDeleted is set
+func (r PostgresStudentRepository) GetStudents(ctx context.Context, search string, includeDeleted bool) ([]*model.Student, error) {
+	query := `SELECT ` + studentColumns + ` FROM student s`
 	args := []any{}
+	conditions := []string{}
+
+	if !includeDeleted {
+		conditions = append(conditions, `s.deleted_at IS NULL`)
+	}
+
+	condition, args := searchCondition("CONCAT_WS(' ', s.name, s.email, s.cpf)", search, args)
+	if condition != "" {
+		conditions = append(conditions, condition)
+	}
+
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
 
-	if search != "" {
-		searchTerms := strings.Fields(search)
+	return r.queryStudents(ctx, query, args...)
+}
+
+// GetStudentCourses returns the courses the student is currently enrolled in,
+// optionally filtered by a search on the course name
+func (r PostgresStudentRepository) GetStudentCourses(ctx context.Context, studentID int, search string) ([]*model.Course, error) {
+	query := `
+		SELECT ` + courseColumns + `
+		FROM enrollment e
+		JOIN course c ON c.id = e.course_id
+		WHERE e.student_id = $1 AND e.status IN ('pending', 'active') AND c.deleted_at IS NULL`
+	args := []any{studentID}
+
+	condition, args := searchCondition("c.name", search, args)
+	if condition != "" {
+		query += ` AND ` + condition
+	}
+
+	query += ` ORDER BY c.id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-		query += ` WHERE `
-		conditions := []string{}
+	var courses []*model.Course
 
-		for i, term := range searchTerms {
-			conditions = append(conditions, fmt.Sprintf("LOWER(UNACCENT(name)) ILIKE LOWER(UNACCENT($%d))", i+1))
-			args = append(args, "%"+term+"%")
+	for rows.Next() {
+		course, err := scanCourse(rows)
+		if err != nil {
+			return nil, err
 		}
 
-		query += strings.Join(conditions, " AND ")
+		courses = append(courses, course)
 	}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return courses, nil
+}
+
+func (r PostgresStudentRepository) queryStudents(ctx context.Context, query string, args ...any) ([]*model.Student, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -56,11 +137,11 @@ func (r PostgresStudentRepository) GetStudents(ctx context.Context, search strin
 	var students []*model.Student
 
 	for rows.Next() {
-		var student model.Student
-		if err := rows.Scan(&student.ID, &student.Name); err != nil {
+		student, err := scanStudent(rows)
+		if err != nil {
 			return nil, err
 		}
-		students = append(students, &student)
+		students = append(students, student)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -70,34 +151,87 @@ func (r PostgresStudentRepository) GetStudents(ctx context.Context, search strin
 	return students, nil
 }
 
+// GetStudent returns the student unless they are deleted
 func (r PostgresStudentRepository) GetStudent(ctx context.Context, id int) (*model.Student, error) {
-	query := `SELECT id, name FROM student WHERE id = $1`
+	query := `SELECT ` + studentColumns + ` FROM student s WHERE s.id = $1 AND s.deleted_at IS NULL`
 
-	row := r.db.QueryRowContext(ctx, query, id)
+	return scanStudent(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+}
 
-	var student model.Student
-	if err := row.Scan(&student.ID, &student.Name); err != nil {
-		return nil, err
-	}
+// GetStudentIncludingDeleted returns the student whether they are deleted or
+// not
+func (r PostgresStudentRepository) GetStudentIncludingDeleted(ctx context.Context, id int) (*model.Student, error) {
+	query := `SELECT ` + studentColumns + ` FROM student s WHERE s.id = $1`
 
-	return &student, nil
+	return scanStudent(conn(ctx, r.db).QueryRowContext(ctx, query, id))
 }
 
 func (r PostgresStudentRepository) UpdateStudent(ctx context.Context, student *model.Student) error {
-	query := `UPDATE student SET name = $1 WHERE id = $2`
+	query := `UPDATE student SET name = $1, email = $2, phone = $3, birth_date = $4, cpf = $5 WHERE id = $6`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, student.Name, student.Email, student.Phone, student.BirthDate, student.CPF, student.ID)
+	if err != nil {
+		return studentConflict(err)
+	}
+
+	return nil
+}
+
+// SetMaxCourses stores the enrollment limit override of the student. A nil
+// maxCourses clears the override.
+func (r PostgresStudentRepository) SetMaxCourses(ctx context.Context, studentID int, maxCourses *int) error {
+	query := `UPDATE student SET max_courses = $1 WHERE id = $2 AND deleted_at IS NULL`
 
-	_, err := r.db.ExecContext(ctx, query, student.Name, student.ID)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, maxCourses, studentID)
 	if err != nil {
 		return err
 	}
 
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrStudentNotFound
+	}
+
 	return nil
 }
 
+// DeleteStudent soft deletes the student, keeping their enrollment records.
+// They leave every waitlist, so they are never promoted while deleted.
 func (r PostgresStudentRepository) DeleteStudent(ctx context.Context, id int) error {
-	query := `DELETE FROM student WHERE id = $1`
+	query := `UPDATE student SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrStudentNotFound
+	}
+
+	query = `DELETE FROM waitlist WHERE student_id = $1`
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
 
-	_, err := r.db.ExecContext(ctx, query, id)
+func (r PostgresStudentRepository) RestoreStudent(ctx context.Context, id int) error {
+	query := `UPDATE student SET deleted_at = NULL WHERE id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -105,10 +239,38 @@ func (r PostgresStudentRepository) DeleteStudent(ctx context.Context, id int) er
 	return nil
 }
 
+// PurgeStudents permanently removes the students deleted before deletedBefore
+// along with their enrollments, returning how many were removed. The
+// enrollment history is kept.
+func (r PostgresStudentRepository) PurgeStudents(ctx context.Context, deletedBefore time.Time) (int, error) {
+	query := `
+		DELETE FROM enrollment
+		WHERE student_id IN (SELECT id FROM student WHERE deleted_at < $1)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	query = `DELETE FROM student WHERE deleted_at < $1`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
 func (r PostgresStudentRepository) EnrolledInHowManyCourses(ctx context.Context, studentID int) (int, error) {
-	query := `SELECT COUNT(*) FROM enrollment WHERE student_id = $1`
+	query := `SELECT COUNT(*) FROM enrollment WHERE student_id = $1 AND status IN ('pending', 'active')`
 
-	row := r.db.QueryRowContext(ctx, query, studentID)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, studentID)
 
 	var count int
 	if err := row.Scan(&count); err != nil {
@@ -117,3 +279,50 @@ func (r PostgresStudentRepository) EnrolledInHowManyCourses(ctx context.Context,
 
 	return count, nil
 }
+
+// LockStudent takes a row lock on the student until the surrounding
+// transaction ends, serializing concurrent enrollments of the same student.
+// Deleted students can't be locked.
+func (r PostgresStudentRepository) LockStudent(ctx context.Context, studentID int) error {
+	query := `SELECT id FROM student WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, studentID)
+	var id int
+	if err := row.Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrStudentNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresStudentRepository) GetEnrolledCourseIDs(ctx context.Context, studentID int) ([]int, error) {
+	query := `
+		SELECT course_id FROM enrollment
+		WHERE student_id = $1 AND status IN ('pending', 'active')
+		ORDER BY course_id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courseIDs []int
+
+	for rows.Next() {
+		var courseID int
+		if err := rows.Scan(&courseID); err != nil {
+			return nil, err
+		}
+		courseIDs = append(courseIDs, courseID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return courseIDs, nil
+}
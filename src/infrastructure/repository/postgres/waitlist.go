@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository"
+)
+
+type PostgresWaitlistRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresWaitlistRepository(db *sql.DB) *PostgresWaitlistRepository {
+	return &PostgresWaitlistRepository{db: db}
+}
+
+// AddStudentToWaitlist puts the student at the end of the waitlist of the
+// offering. The student can only wait for one offering per course.
+func (r PostgresWaitlistRepository) AddStudentToWaitlist(ctx context.Context, offering *model.CourseOffering, studentID int) error {
+	query := `INSERT INTO waitlist (course_id, offering_id, student_id) VALUES ($1, $2, $3)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, offering.CourseID, offering.ID, studentID)
+	if err != nil {
+		if constraint, ok := uniqueViolation(err); ok && constraint == "unique_waitlist_student_course" {
+			return repository.ErrStudentAlreadyInWaitlist
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r PostgresWaitlistRepository) RemoveStudentFromWaitlist(ctx context.Context, courseID, studentID int) error {
+	query := `DELETE FROM waitlist WHERE course_id = $1 AND student_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, courseID, studentID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return repository.ErrStudentNotInWaitlist
+	}
+
+	return nil
+}
+
+// GetWaitlist returns the waitlist of a course in FIFO order, with positions
+// counted per offering
+func (r PostgresWaitlistRepository) GetWaitlist(ctx context.Context, courseID int) ([]*model.WaitlistEntry, error) {
+	query := `
+		SELECT id, course_id, offering_id, student_id, ROW_NUMBER() OVER (PARTITION BY offering_id ORDER BY id), created_at
+		FROM waitlist
+		WHERE course_id = $1
+		ORDER BY id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*model.WaitlistEntry
+
+	for rows.Next() {
+		var entry model.WaitlistEntry
+		if err := rows.Scan(&entry.ID, &entry.CourseID, &entry.OfferingID, &entry.StudentID, &entry.Position, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// WaitlistPosition returns the 1-based position of the student in the
+// waitlist of the offering
+func (r PostgresWaitlistRepository) WaitlistPosition(ctx context.Context, offeringID, studentID int) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM waitlist w
+		JOIN waitlist mine ON mine.offering_id = $1 AND mine.student_id = $2
+		WHERE w.offering_id = $1 AND w.id <= mine.id`
+
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, offeringID, studentID)
+
+	var position int
+	if err := row.Scan(&position); err != nil {
+		return 0, err
+	}
+
+	if position == 0 {
+		return 0, repository.ErrStudentNotInWaitlist
+	}
+
+	return position, nil
+}
@@ -2,26 +2,180 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/felipedavid/vrcursos/src/core/model"
 )
 
 type IStudentRepository interface {
 	Save(ctx context.Context, student *model.Student) error
-	GetStudents(ctx context.Context, search string) ([]*model.Student, error)
+	GetStudents(ctx context.Context, search string, includeDeleted bool) ([]*model.Student, error)
 	GetStudent(ctx context.Context, id int) (*model.Student, error)
+	GetStudentIncludingDeleted(ctx context.Context, id int) (*model.Student, error)
 	UpdateStudent(ctx context.Context, student *model.Student) error
+	SetMaxCourses(ctx context.Context, studentID int, maxCourses *int) error
 	DeleteStudent(ctx context.Context, id int) error
+	RestoreStudent(ctx context.Context, id int) error
+	PurgeStudents(ctx context.Context, deletedBefore time.Time) (int, error)
 	EnrolledInHowManyCourses(ctx context.Context, studentID int) (int, error)
+	LockStudent(ctx context.Context, studentID int) error
+	GetEnrolledCourseIDs(ctx context.Context, studentID int) ([]int, error)
+	GetStudentCourses(ctx context.Context, studentID int, search string) ([]*model.Course, error)
+}
+
+// CourseFilter narrows down the courses listed. Zero values match every course.
+type CourseFilter struct {
+	CategoryID *int
+	Tags       []string
+	Statuses   []string
+	// IncludeDeleted also lists the deleted courses
+	IncludeDeleted bool
 }
 
 type ICourseRepository interface {
 	Save(ctx context.Context, course *model.Course) error
-	GetCourses(ctx context.Context) ([]*model.Course, error)
+	GetCourses(ctx context.Context, filter CourseFilter) ([]*model.Course, error)
 	GetCourse(ctx context.Context, id int) (*model.Course, error)
+	GetCourseIncludingDeleted(ctx context.Context, id int) (*model.Course, error)
 	UpdateCourse(ctx context.Context, course *model.Course) error
+	UpdateCourseStatus(ctx context.Context, course *model.Course) error
+	SetCourseTags(ctx context.Context, courseID int, tags []string) error
 	DeleteCourse(ctx context.Context, id int) error
-	AddStudentToCourse(ctx context.Context, courseID, studentID int) error
-	RemoveStudentFromCourse(ctx context.Context, courseID, studentID int) error
-	HowManyEnrolled(ctx context.Context, courseID int) (int, error)
+	RestoreCourse(ctx context.Context, id int) error
+	PurgeCourses(ctx context.Context, deletedBefore time.Time) (int, error)
+	AddStudentToOffering(ctx context.Context, offering *model.CourseOffering, studentID int, status string) error
+	HowManyEnrolled(ctx context.Context, offeringID int) (int, error)
+	LockCourse(ctx context.Context, courseID int) error
+	IsStudentEnrolled(ctx context.Context, courseID, studentID int) (bool, error)
+	GetEnrollment(ctx context.Context, courseID, studentID int) (*model.Enrollment, error)
+	GetLastEnrollment(ctx context.Context, courseID, studentID int) (*model.Enrollment, error)
+	GetEnrollmentByID(ctx context.Context, id int64) (*model.Enrollment, error)
+	GetCourseStudents(ctx context.Context, courseID int, search string) ([]*model.Student, error)
+	GetOfferingStudents(ctx context.Context, offeringID int, search string) ([]*model.Student, error)
+	GetCourseStudentsAsOf(ctx context.Context, courseID int, asOf time.Time, search string) ([]*model.Student, error)
+	AddEnrollmentEvent(ctx context.Context, event *model.EnrollmentEvent) error
+	UpdateEnrollmentStatus(ctx context.Context, enrollment *model.Enrollment) error
+	LockPrerequisites(ctx context.Context) error
+	AddPrerequisite(ctx context.Context, courseID, prerequisiteID int) error
+	RemovePrerequisite(ctx context.Context, courseID, prerequisiteID int) error
+	GetPrerequisites(ctx context.Context, courseID int) ([]*model.Course, error)
+	RequiresTransitively(ctx context.Context, courseID, prerequisiteID int) (bool, error)
+	GetMissingPrerequisites(ctx context.Context, courseID, studentID int) ([]*model.Course, error)
+	AddSlot(ctx context.Context, slot *model.CourseSlot) error
+	RemoveSlot(ctx context.Context, courseID, slotID int) error
+	GetSlot(ctx context.Context, courseID, slotID int) (*model.CourseSlot, error)
+	GetSlots(ctx context.Context, courseID int) ([]*model.CourseSlot, error)
+	SetSlotRoom(ctx context.Context, courseID, slotID int, roomID *int) error
+	FindScheduleConflict(ctx context.Context, courseID, studentID int) (*model.CourseSlot, error)
+	SaveOffering(ctx context.Context, offering *model.CourseOffering) error
+	GetOffering(ctx context.Context, id int) (*model.CourseOffering, error)
+	GetDefaultOffering(ctx context.Context, courseID int) (*model.CourseOffering, error)
+	GetOfferings(ctx context.Context, courseID int) ([]*model.CourseOffering, error)
+	UpdateOffering(ctx context.Context, offering *model.CourseOffering) error
+}
+
+type IWaitlistRepository interface {
+	AddStudentToWaitlist(ctx context.Context, offering *model.CourseOffering, studentID int) error
+	RemoveStudentFromWaitlist(ctx context.Context, courseID, studentID int) error
+	GetWaitlist(ctx context.Context, courseID int) ([]*model.WaitlistEntry, error)
+	WaitlistPosition(ctx context.Context, offeringID, studentID int) (int, error)
+}
+
+type IGradeRepository interface {
+	GetGrade(ctx context.Context, enrollmentID int64) (*model.Grade, error)
+	SaveGrade(ctx context.Context, grade *model.Grade) error
+	UpdateGrade(ctx context.Context, grade *model.Grade) error
+	AddGradeCorrection(ctx context.Context, correction *model.GradeCorrection) error
+	GetTranscript(ctx context.Context, studentID int) ([]*model.TranscriptEntry, error)
+}
+
+type IAttendanceRepository interface {
+	SaveSession(ctx context.Context, session *model.CourseSession) error
+	GetSession(ctx context.Context, courseID, sessionID int) (*model.CourseSession, error)
+	GetSessions(ctx context.Context, courseID int) ([]*model.CourseSession, error)
+	SaveAttendance(ctx context.Context, attendance *model.Attendance) error
+	GetSessionAttendance(ctx context.Context, sessionID int) ([]*model.Attendance, error)
+	GetCourseAttendance(ctx context.Context, courseID int) (map[int64]*model.AttendanceSummary, error)
+	GetStudentAttendance(ctx context.Context, studentID int) (map[int64]*model.AttendanceSummary, error)
+}
+
+type IInstructorRepository interface {
+	Save(ctx context.Context, instructor *model.Instructor) error
+	GetInstructors(ctx context.Context, search string) ([]*model.Instructor, error)
+	GetInstructor(ctx context.Context, id int) (*model.Instructor, error)
+	UpdateInstructor(ctx context.Context, instructor *model.Instructor) error
+	DeleteInstructor(ctx context.Context, id int) error
+	AssignCourse(ctx context.Context, courseID, instructorID int, role string) error
+	UnassignCourse(ctx context.Context, courseID, instructorID int) error
+	GetInstructorCourses(ctx context.Context, instructorID int) ([]*model.InstructorCourse, error)
+	GetCourseInstructors(ctx context.Context, courseID int) ([]*model.CourseInstructor, error)
+}
+
+type IRoomRepository interface {
+	Save(ctx context.Context, room *model.Room) error
+	GetRooms(ctx context.Context) ([]*model.Room, error)
+	GetRoom(ctx context.Context, id int) (*model.Room, error)
+	UpdateRoom(ctx context.Context, room *model.Room) error
+	DeleteRoom(ctx context.Context, id int) error
+	LockRoom(ctx context.Context, id int) error
+	GetRoomBookings(ctx context.Context, roomID int) ([]*model.RoomBooking, error)
+	GetCourseRooms(ctx context.Context, courseID int) ([]*model.Room, error)
+	MaxBookedCapacity(ctx context.Context, roomID int) (int, error)
+}
+
+type ICategoryRepository interface {
+	Save(ctx context.Context, category *model.Category) error
+	GetCategories(ctx context.Context) ([]*model.Category, error)
+	GetCategory(ctx context.Context, id int) (*model.Category, error)
+	UpdateCategory(ctx context.Context, category *model.Category) error
+	DeleteCategory(ctx context.Context, id int) error
+	LockCategories(ctx context.Context) error
+	IsDescendant(ctx context.Context, id, ancestorID int) (bool, error)
+	CountCourses(ctx context.Context, id int) (int, error)
+	ReassignCourses(ctx context.Context, fromID, toID int) error
+	ReparentChildren(ctx context.Context, id int, parentID *int64) error
+}
+
+type IInvoiceRepository interface {
+	SaveInvoice(ctx context.Context, invoice *model.Invoice) error
+	SetInvoiceCharge(ctx context.Context, invoice *model.Invoice) error
+	GetInvoice(ctx context.Context, id int) (*model.Invoice, error)
+	GetInvoiceByReference(ctx context.Context, gateway, reference string) (*model.Invoice, error)
+	GetOpenInvoice(ctx context.Context, enrollmentID int64) (*model.Invoice, error)
+	GetStudentInvoices(ctx context.Context, studentID int) ([]*model.Invoice, error)
+	GetExpiredInvoices(ctx context.Context, now time.Time) ([]*model.Invoice, error)
+	LockInvoice(ctx context.Context, id int64) error
+	UpdateInvoiceStatus(ctx context.Context, invoice *model.Invoice) error
+	SavePaymentTask(ctx context.Context, task *model.PaymentTask) error
+	GetPendingPaymentTasks(ctx context.Context) ([]*model.PaymentTask, error)
+	GetInvoicePendingPaymentTasks(ctx context.Context, invoiceID int64) ([]*model.PaymentTask, error)
+	ClaimPaymentTask(ctx context.Context, id int64) (*model.PaymentTask, error)
+	FinishPaymentTask(ctx context.Context, task *model.PaymentTask) error
+	FailPaymentTask(ctx context.Context, task *model.PaymentTask, reason string) error
+}
+
+type ICouponRepository interface {
+	SaveCoupon(ctx context.Context, coupon *model.Coupon) error
+	GetCoupons(ctx context.Context) ([]*model.Coupon, error)
+	GetCoupon(ctx context.Context, id int) (*model.Coupon, error)
+	GetCouponByCode(ctx context.Context, code string) (*model.Coupon, error)
+	UpdateCoupon(ctx context.Context, coupon *model.Coupon) error
+	DeleteCoupon(ctx context.Context, id int) error
+	SetCouponCourses(ctx context.Context, couponID int64, courseIDs []int64) error
+	RedeemCoupon(ctx context.Context, couponID int64) error
+	SaveRedemption(ctx context.Context, couponID, enrollmentID, discount int64) error
+	ReleaseRedemption(ctx context.Context, enrollmentID int64) error
+}
+
+type IScholarshipRepository interface {
+	SaveScholarship(ctx context.Context, scholarship *model.Scholarship) error
+	GetStudentScholarships(ctx context.Context, studentID int) ([]*model.Scholarship, error)
+	GetBestScholarship(ctx context.Context, studentID, courseID int, now time.Time) (*model.Scholarship, error)
+	DeleteScholarship(ctx context.Context, studentID, id int) error
+}
+
+// ITransactor runs a function inside a single database transaction. Repository
+// calls made with the ctx passed to fn take part in that transaction.
+type ITransactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
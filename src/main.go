@@ -1,20 +1,42 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/felipedavid/vrcursos/src/application/controllers"
 	"github.com/felipedavid/vrcursos/src/application/routes"
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
 	"github.com/felipedavid/vrcursos/src/infrastructure/database"
+	"github.com/felipedavid/vrcursos/src/infrastructure/payment"
+	"github.com/felipedavid/vrcursos/src/infrastructure/payment/fake"
 	"github.com/felipedavid/vrcursos/src/infrastructure/repository/postgres"
 	"github.com/joho/godotenv"
 )
 
 const (
 	migrationsPath = "file://migrations"
+
+	defaultMaxCoursesPerStudent = 3
+	defaultMinAttendancePercent = 75
+	defaultInvoiceTTLMinutes    = 24 * 60
+	defaultPaymentBaseURL       = "http://localhost:3000"
+	defaultRetentionDays        = 30
+
+	// developmentEnv is the APP_ENV of local setups, the only one where the
+	// fake payment gateway is allowed
+	developmentEnv = "development"
+
+	// paymentInterval is how often unpaid invoices are checked for expiration
+	// and the pending calls to the payment gateway are made
+	paymentInterval = time.Minute
 )
 
 func main() {
@@ -26,16 +48,61 @@ func main() {
 
 	addr := os.Getenv("ADDR")
 	databaseUrl := os.Getenv("DATABASE_URL")
+	maxCoursesPerStudent := intFromEnv("MAX_COURSES_PER_STUDENT", defaultMaxCoursesPerStudent)
+	minAttendance := float64(intFromEnv("MIN_ATTENDANCE_PERCENT", defaultMinAttendancePercent))
+	certificateKey := certificateKeyFromEnv()
+	adminTokens := adminTokensFromEnv()
+	invoiceTTL := time.Duration(intFromEnv("INVOICE_TTL_MINUTES", defaultInvoiceTTLMinutes)) * time.Minute
+	retention := time.Duration(intFromEnv("DELETED_RETENTION_DAYS", defaultRetentionDays)) * 24 * time.Hour
+
+	gateway := gatewayFromEnv(os.Getenv("APP_ENV"))
 
 	db := setupDatabase(databaseUrl)
 
-	studentRepo := postgres.NewPostgresStudentRepository(db)
-	courseRepo := postgres.NewPostgresCourseRepository(db)
+	deps := usecase.Deps{
+		CourseRepository:      postgres.NewPostgresCourseRepository(db),
+		StudentRepository:     postgres.NewPostgresStudentRepository(db),
+		WaitlistRepository:    postgres.NewPostgresWaitlistRepository(db),
+		AttendanceRepository:  postgres.NewPostgresAttendanceRepository(db),
+		InstructorRepository:  postgres.NewPostgresInstructorRepository(db),
+		RoomRepository:        postgres.NewPostgresRoomRepository(db),
+		CategoryRepository:    postgres.NewPostgresCategoryRepository(db),
+		InvoiceRepository:     postgres.NewPostgresInvoiceRepository(db),
+		CouponRepository:      postgres.NewPostgresCouponRepository(db),
+		ScholarshipRepository: postgres.NewPostgresScholarshipRepository(db),
+		Transactor:            postgres.NewPostgresTransactor(db),
+		Gateway:               gateway,
+	}
+	config := usecase.Config{
+		InvoiceTTL:        invoiceTTL,
+		DefaultMaxCourses: maxCoursesPerStudent,
+		MinAttendance:     minAttendance,
+	}
+	gradeRepo := postgres.NewPostgresGradeRepository(db)
+
+	// "purge" runs once and exits instead of serving, so it can be scheduled
+	// outside of the server, like with cron
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		purgeDeleted(usecase.NewStudentUsecase(deps, config), usecase.NewCourseUsecase(deps, config), retention)
+		return
+	}
 
-	userControllers := controllers.NewStudentController(studentRepo)
-	courseControllers := controllers.NewCourseController(courseRepo, studentRepo)
+	userControllers := controllers.NewStudentController(deps, config)
+	courseControllers := controllers.NewCourseController(deps, config)
 
-	routes := routes.DefineRoutes(userControllers, courseControllers)
+	gradeControllers := controllers.NewGradeController(gradeRepo, deps.CourseRepository, deps.StudentRepository, deps.Transactor)
+	attendanceControllers := controllers.NewAttendanceController(deps.AttendanceRepository, deps.CourseRepository, deps.Transactor)
+	certificateControllers := controllers.NewCertificateController(deps.CourseRepository, deps.StudentRepository, certificateKey)
+	instructorControllers := controllers.NewInstructorController(deps.InstructorRepository, deps.CourseRepository)
+	roomControllers := controllers.NewRoomController(deps.RoomRepository, deps.CourseRepository, deps.Transactor)
+	categoryControllers := controllers.NewCategoryController(deps.CategoryRepository, deps.Transactor)
+	couponControllers := controllers.NewCouponController(deps)
+	scholarshipControllers := controllers.NewScholarshipController(deps)
+	paymentControllers := controllers.NewPaymentController(deps, config)
+
+	routes := routes.DefineRoutes(userControllers, courseControllers, gradeControllers, attendanceControllers, certificateControllers, instructorControllers, roomControllers, categoryControllers, paymentControllers, couponControllers, scholarshipControllers, adminTokens)
+
+	go processPayments(usecase.NewPaymentUsecase(deps, config))
 
 	slog.Info("Starting web server", "addr", addr)
 	err = http.ListenAndServe(addr, routes)
@@ -43,6 +110,152 @@ func main() {
 	os.Exit(-1)
 }
 
+// processPayments periodically expires the unpaid invoices past their due
+// date, releasing the seats held by their enrollments, and then makes the
+// calls to the payment gateway recorded meanwhile
+func processPayments(paymentUsecase usecase.PaymentUsecase) {
+	ticker := time.NewTicker(paymentInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		expired, err := paymentUsecase.ExpireInvoices(context.Background(), now)
+		if err != nil {
+			slog.Error("Unable to expire invoices", "err", err)
+		}
+
+		if expired > 0 {
+			slog.Info("Expired unpaid invoices", "count", expired)
+		}
+
+		processed, err := paymentUsecase.ProcessPaymentTasks(context.Background())
+		if err != nil {
+			slog.Error("Unable to process payment tasks", "err", err)
+		}
+
+		if processed > 0 {
+			slog.Info("Processed payment tasks", "count", processed)
+		}
+	}
+}
+
+// purgeDeleted permanently removes the students and courses deleted longer
+// than retention ago
+func purgeDeleted(studentUsecase usecase.StudentUsecase, courseUsecase usecase.CourseUsecase, retention time.Duration) {
+	ctx := context.Background()
+	deletedBefore := time.Now().Add(-retention)
+
+	students, err := studentUsecase.PurgeStudents(ctx, deletedBefore)
+	if err != nil {
+		slog.Error("Unable to purge deleted students", "err", err)
+		os.Exit(-1)
+	}
+
+	courses, err := courseUsecase.PurgeCourses(ctx, deletedBefore)
+	if err != nil {
+		slog.Error("Unable to purge deleted courses", "err", err)
+		os.Exit(-1)
+	}
+
+	slog.Info("Purged deleted records", "students", students, "courses", courses, "deleted_before", deletedBefore)
+}
+
+// intFromEnv reads a non negative integer from the environment, falling back to
+// the given value when the variable is not set
+func intFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		slog.Error("Invalid value in environment variable", "key", key, "value", value)
+		os.Exit(-1)
+	}
+
+	return n
+}
+
+// adminTokensFromEnv reads the tokens of the administrators, written as
+// name:token pairs separated by commas. Without them nobody can do what only
+// administrators can, like overriding enrollment windows.
+func adminTokensFromEnv() map[string]string {
+	tokens := map[string]string{}
+
+	value := os.Getenv("ADMIN_TOKENS")
+	if value == "" {
+		slog.Warn("ADMIN_TOKENS is not set, no administrator can authenticate")
+		return tokens
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		name, token, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || name == "" || token == "" {
+			slog.Error("Invalid value in environment variable, expected name:token pairs", "key", "ADMIN_TOKENS")
+			os.Exit(-1)
+		}
+		tokens[name] = token
+	}
+
+	return tokens
+}
+
+// gatewayFromEnv builds the payment gateway named by PAYMENT_GATEWAY. The fake
+// gateway lets anyone holding its secret mark invoices as paid, so the server
+// refuses to start with it outside of development.
+func gatewayFromEnv(appEnv string) payment.IPaymentGateway {
+	name := os.Getenv("PAYMENT_GATEWAY")
+
+	switch name {
+	case "fake":
+		if appEnv != developmentEnv {
+			slog.Error("The fake payment gateway is only allowed in development", "key", "APP_ENV", "value", appEnv)
+			os.Exit(-1)
+		}
+
+		secret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+		if secret == "" {
+			slog.Error("PAYMENT_WEBHOOK_SECRET is required to authenticate the payment notifications")
+			os.Exit(-1)
+		}
+
+		baseURL := os.Getenv("PAYMENT_BASE_URL")
+		if baseURL == "" {
+			baseURL = defaultPaymentBaseURL
+		}
+
+		return fake.NewFakeGateway(baseURL, []byte(secret))
+	case "":
+		slog.Error("PAYMENT_GATEWAY is not set")
+	default:
+		slog.Error("Unknown payment gateway", "key", "PAYMENT_GATEWAY", "value", name)
+	}
+
+	os.Exit(-1)
+	return nil
+}
+
+// certificateKeyFromEnv reads the key signing the certificate codes. Without
+// one a random key is used, so the certificates issued stop verifying once the
+// server restarts.
+func certificateKeyFromEnv() []byte {
+	key := os.Getenv("CERTIFICATE_SECRET")
+	if key != "" {
+		return []byte(key)
+	}
+
+	slog.Warn("CERTIFICATE_SECRET is not set, using a random key")
+
+	random := make([]byte, 32)
+	_, err := rand.Read(random)
+	if err != nil {
+		slog.Error("Unable to generate certificate key", "err", err)
+		os.Exit(-1)
+	}
+
+	return random
+}
+
 // setupDatabase stablishes a connection to the database and runs the migrations
 func setupDatabase(databaseUrl string) *sql.DB {
 	db, err := database.ConnectToDatabase(databaseUrl)
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+)
+
+// postConcurrently fires a POST to every url at the same time and returns the
+// status codes of the responses, in the order of the urls
+func postConcurrently(handler http.Handler, urls []string) []int {
+	codes := make([]int, len(urls))
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			<-start
+
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, httptest.NewRequest(http.MethodPost, url, nil))
+			codes[i] = res.Code
+		}(i, url)
+	}
+
+	close(start)
+	wg.Wait()
+
+	return codes
+}
+
+func TestCappedCouponIsNotOverRedeemedConcurrently(t *testing.T) {
+	handler := newTestHandler()
+	courseID := createPaidTestCourse(t, "coupon course", 10000, 10)
+
+	res := doRequest(t, handler, http.MethodPost, "/coupons",
+		`{"code": "capped-twice", "discount_type": "percent", "value": 10, "max_redemptions": 2}`)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create coupon: expected 201, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var coupon model.Coupon
+	if err := json.Unmarshal(res.Body.Bytes(), &coupon); err != nil {
+		t.Fatal(err)
+	}
+
+	var urls []string
+	for i := 0; i < 6; i++ {
+		studentID := createTestStudent(t, fmt.Sprintf("coupon student %d", i))
+		urls = append(urls, enrollURL(studentID, courseID)+"?coupon=CAPPED-TWICE")
+	}
+
+	redeemed, exhausted := 0, 0
+	for _, code := range postConcurrently(handler, urls) {
+		switch code {
+		case http.StatusAccepted:
+			redeemed++
+		case http.StatusConflict:
+			exhausted++
+		default:
+			t.Errorf("unexpected status %d", code)
+		}
+	}
+
+	if redeemed != 2 || exhausted != 4 {
+		t.Errorf("expected 2 enrollments with the coupon and 4 rejected, got %d and %d", redeemed, exhausted)
+	}
+
+	var redemptions int
+	err := testDB.QueryRow(`SELECT redemptions FROM coupon WHERE id = $1`, coupon.ID).Scan(&redemptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redemptions != 2 {
+		t.Errorf("expected the coupon to be redeemed 2 times, got %d", redemptions)
+	}
+}
@@ -0,0 +1,606 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/application/controllers"
+	"github.com/felipedavid/vrcursos/src/application/routes"
+	"github.com/felipedavid/vrcursos/src/core/domain"
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/payment/fake"
+	"github.com/felipedavid/vrcursos/src/infrastructure/repository/postgres"
+)
+
+const (
+	testAdminName  = "test admin"
+	testAdminToken = "test-admin-token"
+)
+
+// testGateway signs the payment notifications sent by the tests
+var testGateway = fake.NewFakeGateway("http://localhost", []byte("test-webhook-secret"))
+
+// testConfig is the configuration the tests run the usecases with
+var testConfig = usecase.Config{
+	InvoiceTTL:        time.Hour,
+	DefaultMaxCourses: 3,
+	MinAttendance:     75,
+}
+
+func newTestDeps() usecase.Deps {
+	return usecase.Deps{
+		CourseRepository:      postgres.NewPostgresCourseRepository(testDB),
+		StudentRepository:     postgres.NewPostgresStudentRepository(testDB),
+		WaitlistRepository:    postgres.NewPostgresWaitlistRepository(testDB),
+		AttendanceRepository:  postgres.NewPostgresAttendanceRepository(testDB),
+		InstructorRepository:  postgres.NewPostgresInstructorRepository(testDB),
+		RoomRepository:        postgres.NewPostgresRoomRepository(testDB),
+		CategoryRepository:    postgres.NewPostgresCategoryRepository(testDB),
+		InvoiceRepository:     postgres.NewPostgresInvoiceRepository(testDB),
+		CouponRepository:      postgres.NewPostgresCouponRepository(testDB),
+		ScholarshipRepository: postgres.NewPostgresScholarshipRepository(testDB),
+		Transactor:            postgres.NewPostgresTransactor(testDB),
+		Gateway:               testGateway,
+	}
+}
+
+func newTestHandler() http.Handler {
+	deps := newTestDeps()
+	config := testConfig
+
+	return routes.DefineRoutes(
+		controllers.NewStudentController(deps, config),
+		controllers.NewCourseController(deps, config),
+		controllers.NewGradeController(postgres.NewPostgresGradeRepository(testDB), deps.CourseRepository, deps.StudentRepository, deps.Transactor),
+		controllers.NewAttendanceController(deps.AttendanceRepository, deps.CourseRepository, deps.Transactor),
+		controllers.NewCertificateController(deps.CourseRepository, deps.StudentRepository, []byte("test")),
+		controllers.NewInstructorController(deps.InstructorRepository, deps.CourseRepository),
+		controllers.NewRoomController(deps.RoomRepository, deps.CourseRepository, deps.Transactor),
+		controllers.NewCategoryController(deps.CategoryRepository, deps.Transactor),
+		controllers.NewPaymentController(deps, config),
+		controllers.NewCouponController(deps),
+		controllers.NewScholarshipController(deps),
+		map[string]string{testAdminName: testAdminToken},
+	)
+}
+
+func createTestStudent(t *testing.T, name string) int {
+	t.Helper()
+
+	student := &model.Student{Name: name}
+	err := postgres.NewPostgresStudentRepository(testDB).Save(context.Background(), student)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return int(student.ID)
+}
+
+func createTestCourse(t *testing.T, name string) int {
+	t.Helper()
+
+	repo := postgres.NewPostgresCourseRepository(testDB)
+
+	course := &model.Course{Name: name, Description: name, Status: model.CourseStatusPublished}
+	err := repo.Save(context.Background(), course)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = repo.SaveOffering(context.Background(), &model.CourseOffering{CourseID: course.ID, Name: "Default", Capacity: 10, IsDefault: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return int(course.ID)
+}
+
+// enrollConcurrently fires every enrollment at the same time and returns how
+// many of them succeeded
+func enrollConcurrently(t *testing.T, handler http.Handler, pairs [][2]int) int {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+
+	start := make(chan struct{})
+	for _, pair := range pairs {
+		wg.Add(1)
+		go func(studentID, courseID int) {
+			defer wg.Done()
+			<-start
+
+			url := fmt.Sprintf("/enroll/student/%d/course/%d", studentID, courseID)
+			req := httptest.NewRequest(http.MethodPost, url, nil)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			if res.Code == http.StatusOK {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}(pair[0], pair[1])
+	}
+
+	close(start)
+	wg.Wait()
+
+	return succeeded
+}
+
+// doRequest sends a request with an optional JSON body straight to the handler
+func doRequest(t *testing.T, handler http.Handler, method, url, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	return doRequestAs(t, handler, "", method, url, body)
+}
+
+// doRequestAs sends the request authenticated with the given bearer token, an
+// empty token sends it unauthenticated
+func doRequestAs(t *testing.T, handler http.Handler, token, method, url, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req := httptest.NewRequest(method, url, reader)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	return res
+}
+
+func enrollURL(studentID, courseID int) string {
+	return fmt.Sprintf("/enroll/student/%d/course/%d", studentID, courseID)
+}
+
+func countEnrollments(t *testing.T, column string, id int) int {
+	t.Helper()
+
+	var count int
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM enrollment WHERE %s = $1 AND status IN ('pending', 'active')`, column)
+	if err := testDB.QueryRow(query, id).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+
+	return count
+}
+
+func TestEnrollStudentConcurrentlyRespectsCourseLimit(t *testing.T) {
+	handler := newTestHandler()
+	courseID := createTestCourse(t, "concurrent course")
+
+	var pairs [][2]int
+	for i := 0; i < 50; i++ {
+		studentID := createTestStudent(t, fmt.Sprintf("concurrent student %d", i))
+		pairs = append(pairs, [2]int{studentID, courseID})
+	}
+
+	succeeded := enrollConcurrently(t, handler, pairs)
+
+	enrolled := countEnrollments(t, "course_id", courseID)
+	if enrolled > 10 {
+		t.Fatalf("course has %d students enrolled, limit is 10", enrolled)
+	}
+	if succeeded != enrolled {
+		t.Fatalf("%d enrollments succeeded but %d were stored", succeeded, enrolled)
+	}
+	if enrolled != 10 {
+		t.Fatalf("expected the course to be filled with 10 students, got %d", enrolled)
+	}
+}
+
+func TestEnrollStudentConcurrentlyRespectsStudentLimit(t *testing.T) {
+	handler := newTestHandler()
+	studentID := createTestStudent(t, "busy student")
+
+	var pairs [][2]int
+	for i := 0; i < 20; i++ {
+		courseID := createTestCourse(t, fmt.Sprintf("busy course %d", i))
+		pairs = append(pairs, [2]int{studentID, courseID})
+	}
+
+	succeeded := enrollConcurrently(t, handler, pairs)
+
+	enrolled := countEnrollments(t, "student_id", studentID)
+	if enrolled > 3 {
+		t.Fatalf("student is enrolled in %d courses, limit is 3", enrolled)
+	}
+	if succeeded != enrolled {
+		t.Fatalf("%d enrollments succeeded but %d were stored", succeeded, enrolled)
+	}
+	if enrolled != 3 {
+		t.Fatalf("expected the student to be enrolled in 3 courses, got %d", enrolled)
+	}
+}
+
+func TestEnrollStudentOverLimitIsRejected(t *testing.T) {
+	handler := newTestHandler()
+	studentID := createTestStudent(t, "student at the limit")
+
+	for i := 0; i < 3; i++ {
+		courseID := createTestCourse(t, fmt.Sprintf("limit course %d", i))
+		res := doRequest(t, handler, http.MethodPost, enrollURL(studentID, courseID), "")
+		if res.Code != http.StatusOK {
+			t.Fatalf("enrollment %d failed with %d: %s", i, res.Code, res.Body)
+		}
+	}
+
+	courseID := createTestCourse(t, "one course too many")
+	res := doRequest(t, handler, http.MethodPost, enrollURL(studentID, courseID), "")
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected enrolling over the limit to fail with %d, got %d: %s", http.StatusBadRequest, res.Code, res.Body)
+	}
+
+	if enrolled := countEnrollments(t, "student_id", studentID); enrolled != 3 {
+		t.Fatalf("expected the student to stay in 3 courses, got %d", enrolled)
+	}
+}
+
+func TestOverrideEnrollmentWindowRequiresAdmin(t *testing.T) {
+	handler := newTestHandler()
+	studentID := createTestStudent(t, "late student")
+	courseID := createTestCourse(t, "closed course")
+
+	_, err := testDB.Exec(`UPDATE course_offering SET enrollment_closes_at = NOW() - INTERVAL '1 day' WHERE course_id = $1`, courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := enrollURL(studentID, courseID) + "?override_window=true"
+
+	res := doRequest(t, handler, http.MethodPost, url, "")
+	if res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), domain.ErrWindowOverrideDenied.Error()) {
+		t.Fatalf("expected an anonymous override to be denied, got %d: %s", res.Code, res.Body)
+	}
+
+	res = doRequestAs(t, handler, "not-a-token", http.MethodPost, url, "")
+	if res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected an unknown token to be rejected with %d, got %d", http.StatusUnauthorized, res.Code)
+	}
+
+	res = doRequestAs(t, handler, testAdminToken, http.MethodPost, url, "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected an administrator to override the window, got %d: %s", res.Code, res.Body)
+	}
+}
+
+// createDraftTestCourse creates a course that isn't published yet, with a
+// default offering
+func createDraftTestCourse(t *testing.T, name, description string) int {
+	t.Helper()
+
+	repo := postgres.NewPostgresCourseRepository(testDB)
+
+	course := &model.Course{Name: name, Description: description, Status: model.CourseStatusDraft}
+	err := repo.Save(context.Background(), course)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = repo.SaveOffering(context.Background(), &model.CourseOffering{CourseID: course.ID, Name: "Default", Capacity: 10, IsDefault: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return int(course.ID)
+}
+
+func TestPublishCourseRequiresDescription(t *testing.T) {
+	handler := newTestHandler()
+	courseID := createDraftTestCourse(t, "course without description", "")
+	publishURL := fmt.Sprintf("/courses/%d/publish", courseID)
+
+	res := doRequest(t, handler, http.MethodPost, publishURL, "")
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), usecase.ErrCourseDescriptionRequired.Error()) {
+		t.Fatalf("expected 400 for the missing description, got %d: %s", res.Code, res.Body.String())
+	}
+
+	_, err := testDB.Exec(`UPDATE course SET description = 'now described' WHERE id = $1`, courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res = doRequest(t, handler, http.MethodPost, publishURL, "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 once described, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPost, publishURL, "")
+	if res.Code != http.StatusConflict {
+		t.Errorf("publishing twice: expected 409, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestEnrollRejectsDraftAndArchivedCourses(t *testing.T) {
+	handler := newTestHandler()
+	courseID := createDraftTestCourse(t, "lifecycle course", "lifecycle course")
+	studentID := createTestStudent(t, "lifecycle student")
+
+	res := doRequest(t, handler, http.MethodPost, enrollURL(studentID, courseID), "")
+	if res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), usecase.ErrCourseNotPublished.Error()) {
+		t.Fatalf("draft course: expected 403, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPost, fmt.Sprintf("/courses/%d/publish", courseID), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("publish: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPost, fmt.Sprintf("/courses/%d/archive", courseID), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("archive: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPost, enrollURL(studentID, courseID), "")
+	if res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), usecase.ErrCourseArchived.Error()) {
+		t.Errorf("archived course: expected 403, got %d: %s", res.Code, res.Body.String())
+	}
+
+	if count := countEnrollments(t, "course_id", courseID); count != 0 {
+		t.Errorf("expected no enrollments, got %d", count)
+	}
+
+	res = doRequest(t, handler, http.MethodPost, fmt.Sprintf("/courses/%d/archive", courseID), "")
+	if res.Code != http.StatusConflict {
+		t.Errorf("archiving twice: expected 409, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+// conflictEnrollments returns the enrollment count reported by a 409 on a
+// delete without cascade
+func conflictEnrollments(t *testing.T, res *httptest.ResponseRecorder) int {
+	t.Helper()
+
+	if res.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var body struct {
+		Enrollments int `json:"enrollments"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	return body.Enrollments
+}
+
+func TestDeleteCourseWithEnrollments(t *testing.T) {
+	handler := newTestHandler()
+	courseID := createTestCourse(t, "deleted course")
+	courseURL := fmt.Sprintf("/courses/%d", courseID)
+
+	for i := 0; i < 2; i++ {
+		studentID := createTestStudent(t, fmt.Sprintf("deleted course student %d", i))
+		res := doRequest(t, handler, http.MethodPost, enrollURL(studentID, courseID), "")
+		if res.Code != http.StatusOK {
+			t.Fatalf("enroll: expected 200, got %d: %s", res.Code, res.Body.String())
+		}
+	}
+
+	res := doRequest(t, handler, http.MethodDelete, courseURL, "")
+	if enrollments := conflictEnrollments(t, res); enrollments != 2 {
+		t.Errorf("expected 2 enrollments in the conflict, got %d", enrollments)
+	}
+
+	if count := countEnrollments(t, "course_id", courseID); count != 2 {
+		t.Fatalf("rejected delete changed the enrollments, got %d", count)
+	}
+
+	res = doRequest(t, handler, http.MethodDelete, courseURL+"?cascade=true", "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("cascade delete: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	if count := countEnrollments(t, "course_id", courseID); count != 0 {
+		t.Errorf("expected the enrollments to be dropped, got %d", count)
+	}
+
+	res = doRequest(t, handler, http.MethodGet, courseURL, "")
+	if res.Code != http.StatusNotFound {
+		t.Errorf("deleted course: expected 404, got %d", res.Code)
+	}
+}
+
+func TestDeletedCoursesAreOnlyVisibleToAdmins(t *testing.T) {
+	handler := newTestHandler()
+	courseID := createTestCourse(t, "hidden course")
+	courseURL := fmt.Sprintf("/courses/%d", courseID)
+
+	res := doRequest(t, handler, http.MethodDelete, courseURL, "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	for _, url := range []string{courseURL + "?include_deleted=true", "/courses?include_deleted=true"} {
+		res = doRequest(t, handler, http.MethodGet, url, "")
+		if res.Code != http.StatusForbidden {
+			t.Errorf("anonymous %s: expected 403, got %d", url, res.Code)
+		}
+
+		res = doRequestAs(t, handler, testAdminToken, http.MethodGet, url, "")
+		if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "hidden course") {
+			t.Errorf("admin %s: expected the deleted course, got %d: %s", url, res.Code, res.Body.String())
+		}
+	}
+
+	res = doRequest(t, handler, http.MethodPost, courseURL+"/restore", "")
+	if res.Code != http.StatusForbidden {
+		t.Errorf("anonymous restore: expected 403, got %d", res.Code)
+	}
+
+	res = doRequestAs(t, handler, testAdminToken, http.MethodPost, courseURL+"/restore", "")
+	if res.Code != http.StatusOK {
+		t.Errorf("admin restore: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestDeletedPrerequisiteIsNoLongerRequired(t *testing.T) {
+	handler := newTestHandler()
+	studentID := createTestStudent(t, "student without the prerequisite")
+	prerequisiteID := createTestCourse(t, "retired prerequisite")
+	courseID := createTestCourse(t, "course with a retired prerequisite")
+
+	res := doRequest(t, handler, http.MethodPost, fmt.Sprintf("/courses/%d/prerequisites/%d", courseID, prerequisiteID), "")
+	if res.Code != http.StatusCreated {
+		t.Fatalf("add prerequisite: expected 201, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPost, enrollURL(studentID, courseID), "")
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("missing prerequisite: expected 403, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodDelete, fmt.Sprintf("/courses/%d", prerequisiteID), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("delete prerequisite: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodGet, fmt.Sprintf("/courses/%d/prerequisites", courseID), "")
+	if strings.Contains(res.Body.String(), "retired prerequisite") {
+		t.Errorf("deleted prerequisite was listed: %s", res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPost, enrollURL(studentID, courseID), "")
+	if res.Code != http.StatusOK {
+		t.Errorf("deleted prerequisite: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestRestoringCourseCannotCloseAPrerequisiteCycle(t *testing.T) {
+	handler := newTestHandler()
+	firstID := createTestCourse(t, "first in the cycle")
+	deletedID := createTestCourse(t, "deleted in the cycle")
+	lastID := createTestCourse(t, "last in the cycle")
+
+	addPrerequisite := func(courseID, prerequisiteID int) *httptest.ResponseRecorder {
+		return doRequest(t, handler, http.MethodPost, fmt.Sprintf("/courses/%d/prerequisites/%d", courseID, prerequisiteID), "")
+	}
+
+	for _, pair := range [][2]int{{firstID, deletedID}, {deletedID, lastID}} {
+		res := addPrerequisite(pair[0], pair[1])
+		if res.Code != http.StatusCreated {
+			t.Fatalf("add prerequisite: expected 201, got %d: %s", res.Code, res.Body.String())
+		}
+	}
+
+	res := doRequest(t, handler, http.MethodDelete, fmt.Sprintf("/courses/%d", deletedID), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = addPrerequisite(lastID, firstID)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("prerequisite through a deleted course: expected 201, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequestAs(t, handler, testAdminToken, http.MethodPost, fmt.Sprintf("/courses/%d/restore", deletedID), "")
+	if res.Code != http.StatusConflict || !strings.Contains(res.Body.String(), usecase.ErrPrerequisiteCycle.Error()) {
+		t.Errorf("restore closing a cycle: expected 409, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestWaitlistPositionIsCountedPerOffering(t *testing.T) {
+	handler := newTestHandler()
+	repo := postgres.NewPostgresCourseRepository(testDB)
+
+	courseID := createTestCourse(t, "course with two offerings")
+	_, err := testDB.Exec(`UPDATE course_offering SET capacity = 1 WHERE course_id = $1`, courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evening := &model.CourseOffering{CourseID: int64(courseID), Name: "Evening", Capacity: 1}
+	err = repo.SaveOffering(context.Background(), evening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eveningURL := fmt.Sprintf("/enroll/student/%d/offering/%d", createTestStudent(t, "evening student"), evening.ID)
+
+	res := doRequest(t, handler, http.MethodPost, enrollURL(createTestStudent(t, "default student"), courseID), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected the default offering to take the student, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPost, eveningURL, "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected the evening offering to take the student, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPost, enrollURL(createTestStudent(t, "waiting for default"), courseID), "")
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("expected the default offering to be full, got %d: %s", res.Code, res.Body.String())
+	}
+
+	waitingURL := fmt.Sprintf("/enroll/student/%d/offering/%d", createTestStudent(t, "waiting for evening"), evening.ID)
+	res = doRequest(t, handler, http.MethodPost, waitingURL, "")
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("expected the evening offering to be full, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var body struct {
+		WaitlistPosition int `json:"waitlist_position"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.WaitlistPosition != 1 {
+		t.Errorf("expected to be first in the evening waitlist, got position %d", body.WaitlistPosition)
+	}
+}
+
+func TestWaitlistPromotionIsRecordedAsSystem(t *testing.T) {
+	handler := newTestHandler()
+
+	courseID := createTestCourse(t, "course promoting as system")
+	_, err := testDB.Exec(`UPDATE course_offering SET capacity = 1 WHERE course_id = $1`, courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enrolledID := createTestStudent(t, "student leaving")
+	waitingID := createTestStudent(t, "student promoted")
+
+	res := doRequest(t, handler, http.MethodPost, enrollURL(enrolledID, courseID), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("enroll: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPost, enrollURL(waitingID, courseID), "")
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("expected the second student to be waitlisted, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequestAs(t, handler, testAdminToken, http.MethodDelete, enrollURL(enrolledID, courseID), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("unenroll: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var actor string
+	query := `SELECT actor FROM enrollment_history WHERE course_id = $1 AND student_id = $2 AND event = 'enroll'`
+	if err := testDB.QueryRow(query, courseID, waitingID).Scan(&actor); err != nil {
+		t.Fatal(err)
+	}
+
+	if actor != domain.SystemActor {
+		t.Errorf("expected the promotion to be recorded as %q, got %q", domain.SystemActor, actor)
+	}
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+)
+
+// completeTestEnrollment enrolls the student in a new course and completes
+// the enrollment, returning the course
+func completeTestEnrollment(t *testing.T, handler http.Handler, studentID int, name string) int {
+	t.Helper()
+
+	courseID := createTestCourse(t, name)
+
+	res := doRequest(t, handler, http.MethodPost, enrollURL(studentID, courseID), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("enroll: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	_, err := testDB.Exec(`
+		UPDATE enrollment SET status = 'completed', completed_at = NOW()
+		WHERE course_id = $1 AND student_id = $2`, courseID, studentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return courseID
+}
+
+func gradeURL(studentID, courseID int) string {
+	return fmt.Sprintf("/enroll/student/%d/course/%d/grade", studentID, courseID)
+}
+
+func TestResubmittingFinalizedGradeIsNotACorrection(t *testing.T) {
+	handler := newTestHandler()
+	studentID := createTestStudent(t, "precisely graded student")
+	courseID := completeTestEnrollment(t, handler, studentID, "precisely graded course")
+
+	res := doRequest(t, handler, http.MethodPut, gradeURL(studentID, courseID), `{"value": 7.555, "finalize": true}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("grade: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPut, gradeURL(studentID, courseID), `{"value": 7.555}`)
+	if res.Code != http.StatusOK {
+		t.Errorf("same grade again: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var corrections int
+	query := `
+		SELECT COUNT(*) FROM grade_correction c
+		JOIN grade g ON g.id = c.grade_id
+		JOIN enrollment e ON e.id = g.enrollment_id
+		WHERE e.course_id = $1 AND e.student_id = $2`
+	if err := testDB.QueryRow(query, courseID, studentID).Scan(&corrections); err != nil {
+		t.Fatal(err)
+	}
+	if corrections != 0 {
+		t.Errorf("expected no correction to be recorded, got %d", corrections)
+	}
+}
+
+func TestTranscriptAveragesFinalizedGrades(t *testing.T) {
+	handler := newTestHandler()
+	studentID := createTestStudent(t, "transcript student")
+	finalCourseID := completeTestEnrollment(t, handler, studentID, "finalized transcript course")
+	draftCourseID := completeTestEnrollment(t, handler, studentID, "draft transcript course")
+
+	res := doRequest(t, handler, http.MethodPut, gradeURL(studentID, finalCourseID), `{"value": 8, "finalize": true}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("finalized grade: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPut, gradeURL(studentID, draftCourseID), `{"value": 2}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("draft grade: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodGet, fmt.Sprintf("/students/%d/transcript", studentID), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("transcript: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var transcript usecase.GetTranscriptOutput
+	if err := json.Unmarshal(res.Body.Bytes(), &transcript); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(transcript.Courses) != 2 {
+		t.Errorf("expected both courses to be listed, got %d", len(transcript.Courses))
+	}
+	if transcript.Average == nil || *transcript.Average != 8 {
+		t.Errorf("expected the average of the finalized grade only, got %v", transcript.Average)
+	}
+}
@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/payment/fake"
+)
+
+// createPaidTestCourse creates a published course charging price whose
+// default offering has the given capacity
+func createPaidTestCourse(t *testing.T, name string, price int64, capacity int) int {
+	t.Helper()
+
+	courseID := createTestCourse(t, name)
+
+	_, err := testDB.Exec(`UPDATE course SET price = $1 WHERE id = $2`, price, courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testDB.Exec(`UPDATE course_offering SET capacity = $1 WHERE course_id = $2`, capacity, courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return courseID
+}
+
+// enrollmentStatus returns the status of the latest enrollment of the student
+// in the course
+func enrollmentStatus(t *testing.T, courseID, studentID int) string {
+	t.Helper()
+
+	var status string
+	query := `SELECT status FROM enrollment WHERE course_id = $1 AND student_id = $2 ORDER BY id DESC LIMIT 1`
+	if err := testDB.QueryRow(query, courseID, studentID).Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+
+	return status
+}
+
+// enrollInPaidCourse enrolls the student and returns the invoice of the
+// pending enrollment
+func enrollInPaidCourse(t *testing.T, handler http.Handler, studentID, courseID int) *model.Invoice {
+	t.Helper()
+
+	res := doRequest(t, handler, http.MethodPost, enrollURL(studentID, courseID), "")
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("enroll: expected 202, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var body struct {
+		Invoice *model.Invoice `json:"invoice"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Invoice == nil {
+		t.Fatalf("enroll: expected an invoice, got %s", res.Body.String())
+	}
+
+	return body.Invoice
+}
+
+// sendSignedCallback posts a notification of the charge signed like the test
+// gateway expects
+func sendSignedCallback(t *testing.T, handler http.Handler, reference, status string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body := fmt.Sprintf(`{"reference": %q, "status": %q}`, reference, status)
+	return sendCallback(t, handler, body, testGateway.Sign([]byte(body)))
+}
+
+// sendCallback posts a payment notification with the given signature
+func sendCallback(t *testing.T, handler http.Handler, body, signature string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/payments/callback", strings.NewReader(body))
+	if signature != "" {
+		req.Header.Set(fake.SignatureHeader, signature)
+	}
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestPaymentCallbackRequiresSignature(t *testing.T) {
+	handler := newTestHandler()
+	body := `{"reference": "fake_unknown", "status": "paid"}`
+
+	res := sendCallback(t, handler, body, "")
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("unsigned notification: expected 400, got %d", res.Code)
+	}
+
+	forged := fake.NewFakeGateway("http://localhost", []byte("someone else's secret"))
+	res = sendCallback(t, handler, body, forged.Sign([]byte(body)))
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("notification signed with another secret: expected 400, got %d", res.Code)
+	}
+
+	res = sendCallback(t, handler, body, testGateway.Sign([]byte(body)))
+	if res.Code != http.StatusNotFound {
+		t.Errorf("signed notification of an unknown invoice: expected 404, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestPaymentCallbackActivatesEnrollment(t *testing.T) {
+	handler := newTestHandler()
+	courseID := createPaidTestCourse(t, "paid course", 10000, 1)
+	studentID := createTestStudent(t, "paying student")
+
+	invoice := enrollInPaidCourse(t, handler, studentID, courseID)
+	if invoice.Reference == nil || invoice.PaymentURL == nil {
+		t.Fatal("expected the charge to be created once the enrollment committed")
+	}
+
+	if status := enrollmentStatus(t, courseID, studentID); status != model.EnrollmentStatusPending {
+		t.Fatalf("expected the enrollment to wait for payment, got %s", status)
+	}
+
+	res := sendSignedCallback(t, handler, *invoice.Reference, "paid")
+	if res.Code != http.StatusOK {
+		t.Fatalf("callback: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	if status := enrollmentStatus(t, courseID, studentID); status != model.EnrollmentStatusActive {
+		t.Errorf("expected the paid enrollment to be active, got %s", status)
+	}
+
+	// Gateways may deliver the same notification again
+	res = sendSignedCallback(t, handler, *invoice.Reference, "paid")
+	if res.Code != http.StatusOK {
+		t.Errorf("repeated callback: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestExpiredInvoiceReleasesSeat(t *testing.T) {
+	ctx := context.Background()
+	handler := newTestHandler()
+	paymentUsecase := usecase.NewPaymentUsecase(newTestDeps(), testConfig)
+
+	courseID := createPaidTestCourse(t, "expiring course", 10000, 1)
+	payerID := createTestStudent(t, "student who never pays")
+	waitingID := createTestStudent(t, "student in the waitlist")
+
+	invoice := enrollInPaidCourse(t, handler, payerID, courseID)
+	if invoice.Reference == nil {
+		t.Fatal("expected the charge to be created once the enrollment committed")
+	}
+
+	res := doRequest(t, handler, http.MethodPost, enrollURL(waitingID, courseID), "")
+	if res.Code != http.StatusAccepted || !strings.Contains(res.Body.String(), "waitlist_position") {
+		t.Fatalf("expected the second student to be waitlisted, got %d: %s", res.Code, res.Body.String())
+	}
+
+	expired, err := paymentUsecase.ExpireInvoices(ctx, time.Now().Add(2*testConfig.InvoiceTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired == 0 {
+		t.Fatal("expected the unpaid invoice to expire")
+	}
+
+	if status := enrollmentStatus(t, courseID, payerID); status != model.EnrollmentStatusDropped {
+		t.Errorf("expected the unpaid enrollment to be dropped, got %s", status)
+	}
+	if status := enrollmentStatus(t, courseID, waitingID); status != model.EnrollmentStatusPending {
+		t.Errorf("expected the waitlisted student to take the seat, got %s", status)
+	}
+
+	// Canceling the expired charge and charging the promoted student were left
+	// for after the expiration committed
+	_, err = paymentUsecase.ProcessPaymentTasks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pending int
+	query := `
+		SELECT COUNT(*) FROM payment_task t
+		JOIN invoice i ON i.id = t.invoice_id
+		WHERE i.course_id = $1 AND t.processed_at IS NULL`
+	if err := testDB.QueryRow(query, courseID).Scan(&pending); err != nil {
+		t.Fatal(err)
+	}
+	if pending != 0 {
+		t.Errorf("expected every payment task of the course to be processed, %d are pending", pending)
+	}
+
+	var charged bool
+	query = `SELECT gateway_reference IS NOT NULL FROM invoice WHERE course_id = $1 AND student_id = $2 AND status = 'open'`
+	if err := testDB.QueryRow(query, courseID, waitingID).Scan(&charged); err != nil {
+		t.Fatal(err)
+	}
+	if !charged {
+		t.Error("expected the invoice of the promoted student to be charged")
+	}
+
+	res = sendSignedCallback(t, handler, *invoice.Reference, "paid")
+	if res.Code != http.StatusConflict {
+		t.Errorf("paying the expired invoice: expected 409, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestUpdateCourseKeepsOmittedPrice(t *testing.T) {
+	handler := newTestHandler()
+	courseID := createPaidTestCourse(t, "priced course", 15000, 10)
+	courseURL := fmt.Sprintf("/courses/%d", courseID)
+
+	_, err := testDB.Exec(`UPDATE course SET currency = 'USD' WHERE id = $1`, courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := doRequest(t, handler, http.MethodPut, courseURL, `{"name": "renamed priced course", "description": "renamed"}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var price int64
+	var currency string
+	err = testDB.QueryRow(`SELECT price, currency FROM course WHERE id = $1`, courseID).Scan(&price, &currency)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if price != 15000 || currency != "USD" {
+		t.Errorf("expected the price to stay 15000 USD, got %d %s", price, currency)
+	}
+
+	res = doRequest(t, handler, http.MethodPut, courseURL, `{"name": "renamed priced course", "description": "renamed", "price": 0}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("update to free: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	err = testDB.QueryRow(`SELECT price, currency FROM course WHERE id = $1`, courseID).Scan(&price, &currency)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if price != 0 || currency != "USD" {
+		t.Errorf("expected the course to become free keeping USD, got %d %s", price, currency)
+	}
+}
+
+func TestLatePaymentIsRecordedAndRefunded(t *testing.T) {
+	ctx := context.Background()
+	handler := newTestHandler()
+	paymentUsecase := usecase.NewPaymentUsecase(newTestDeps(), testConfig)
+
+	courseID := createPaidTestCourse(t, "late payment course", 10000, 5)
+	studentID := createTestStudent(t, "student who pays late")
+
+	invoice := enrollInPaidCourse(t, handler, studentID, courseID)
+	if invoice.Reference == nil {
+		t.Fatal("expected the charge to be created once the enrollment committed")
+	}
+
+	_, err := paymentUsecase.ExpireInvoices(ctx, time.Now().Add(2*testConfig.InvoiceTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		res := sendSignedCallback(t, handler, *invoice.Reference, "paid")
+		if res.Code != http.StatusOK {
+			t.Fatalf("late callback %d: expected 200, got %d: %s", i, res.Code, res.Body.String())
+		}
+	}
+
+	late, err := paymentUsecase.GetInvoice(ctx, int(invoice.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if late.Status != model.InvoiceStatusRefunded || late.PaidAt == nil || late.RefundedAt == nil {
+		t.Errorf("expected the late payment to be recorded and refunded, got %+v", late)
+	}
+
+	if status := enrollmentStatus(t, courseID, studentID); status != model.EnrollmentStatusDropped {
+		t.Errorf("expected the expired enrollment to stay dropped, got %s", status)
+	}
+}
@@ -1,13 +1,24 @@
 package controllers
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/felipedavid/vrcursos/src/core/domain/usecase"
+	"github.com/felipedavid/vrcursos/src/core/model"
 	"github.com/felipedavid/vrcursos/src/infrastructure/database"
 	"github.com/joho/godotenv"
 )
 
+var testDB *sql.DB
+
 func TestMain(m *testing.M) {
 	err := godotenv.Load()
 	if err != nil {
@@ -26,6 +37,8 @@ func TestMain(m *testing.M) {
 		panic(err)
 	}
 
+	testDB = db
+
 	m.Run()
 
 	err = database.RunDownMigrations(db, "file://migrations")
@@ -37,3 +50,332 @@ func TestMain(m *testing.M) {
 func TestStudentsList(t *testing.T) {
 
 }
+
+func TestGetStudentIncludesEmptyCourses(t *testing.T) {
+	handler := newTestHandler()
+	studentID := createTestStudent(t, "student without courses")
+
+	res := doRequest(t, handler, http.MethodGet, fmt.Sprintf("/students/%d?include=courses", studentID), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if string(body["courses"]) != "[]" {
+		t.Errorf("expected an empty courses list, got %q", body["courses"])
+	}
+
+	res = doRequest(t, handler, http.MethodGet, fmt.Sprintf("/students/%d", studentID), "")
+	if strings.Contains(res.Body.String(), `"courses"`) {
+		t.Errorf("courses weren't requested but were returned: %s", res.Body.String())
+	}
+}
+
+func TestDeleteAndRestoreStudent(t *testing.T) {
+	handler := newTestHandler()
+	studentID := createTestStudent(t, "restorable student")
+	studentURL := fmt.Sprintf("/students/%d", studentID)
+
+	res := doRequest(t, handler, http.MethodDelete, studentURL, "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodGet, studentURL, "")
+	if res.Code != http.StatusNotFound {
+		t.Errorf("deleted student: expected 404, got %d", res.Code)
+	}
+
+	res = doRequest(t, handler, http.MethodGet, studentURL+"?include_deleted=true", "")
+	if res.Code != http.StatusForbidden {
+		t.Errorf("anonymous include_deleted: expected 403, got %d", res.Code)
+	}
+
+	res = doRequestAs(t, handler, testAdminToken, http.MethodGet, studentURL+"?include_deleted=true", "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("deleted student with include_deleted: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var student model.Student
+	if err := json.Unmarshal(res.Body.Bytes(), &student); err != nil {
+		t.Fatal(err)
+	}
+	if student.DeletedAt == nil {
+		t.Error("expected the deleted student to have deleted_at")
+	}
+
+	res = doRequest(t, handler, http.MethodGet, "/students?search=restorable", "")
+	if strings.Contains(res.Body.String(), "restorable student") {
+		t.Errorf("deleted student was listed: %s", res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodGet, "/students?search=restorable&include_deleted=true", "")
+	if res.Code != http.StatusForbidden {
+		t.Errorf("anonymous list with include_deleted: expected 403, got %d", res.Code)
+	}
+
+	res = doRequestAs(t, handler, testAdminToken, http.MethodGet, "/students?search=restorable&include_deleted=true", "")
+	if !strings.Contains(res.Body.String(), "restorable student") {
+		t.Errorf("deleted student wasn't listed with include_deleted: %s", res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodPost, studentURL+"/restore", "")
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("anonymous restore: expected 403, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequestAs(t, handler, testAdminToken, http.MethodPost, studentURL+"/restore", "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodGet, studentURL, "")
+	if res.Code != http.StatusOK {
+		t.Errorf("restored student: expected 200, got %d", res.Code)
+	}
+
+	res = doRequestAs(t, handler, testAdminToken, http.MethodPost, studentURL+"/restore", "")
+	if res.Code != http.StatusConflict {
+		t.Errorf("restoring a student not deleted: expected 409, got %d", res.Code)
+	}
+}
+
+func TestPurgeRemovesStudentsPastRetention(t *testing.T) {
+	handler := newTestHandler()
+	studentUsecase := usecase.NewStudentUsecase(newTestDeps(), testConfig)
+
+	oldID := createTestStudent(t, "long deleted student")
+	recentID := createTestStudent(t, "recently deleted student")
+
+	for _, id := range []int{oldID, recentID} {
+		res := doRequest(t, handler, http.MethodDelete, fmt.Sprintf("/students/%d", id), "")
+		if res.Code != http.StatusOK {
+			t.Fatalf("delete: expected 200, got %d: %s", res.Code, res.Body.String())
+		}
+	}
+
+	_, err := testDB.Exec(`UPDATE student SET deleted_at = NOW() - INTERVAL '40 days' WHERE id = $1`, oldID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := studentUsecase.PurgeStudents(context.Background(), time.Now().Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged < 1 {
+		t.Errorf("expected the student deleted 40 days ago to be purged, purged %d", purged)
+	}
+
+	var exists bool
+	if err := testDB.QueryRow(`SELECT EXISTS (SELECT 1 FROM student WHERE id = $1)`, oldID).Scan(&exists); err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("expected the student deleted 40 days ago to be gone")
+	}
+
+	if err := testDB.QueryRow(`SELECT EXISTS (SELECT 1 FROM student WHERE id = $1)`, recentID).Scan(&exists); err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Error("expected the recently deleted student to be kept")
+	}
+}
+
+func TestCertificateOfDeletedStudentStillVerifies(t *testing.T) {
+	handler := newTestHandler()
+	deps := newTestDeps()
+	courseID := createTestCourse(t, "certified course")
+	studentID := createTestStudent(t, "certified student")
+
+	res := doRequest(t, handler, http.MethodPost, enrollURL(studentID, courseID), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("enroll: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	_, err := testDB.Exec(`
+		UPDATE enrollment SET status = 'completed', completed_at = NOW()
+		WHERE course_id = $1 AND student_id = $2`, courseID, studentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificates := usecase.NewCertificateUsecase(deps.CourseRepository, deps.StudentRepository, []byte("test"))
+	certificate, err := certificates.GetCertificate(context.Background(), courseID, studentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, url := range []string{fmt.Sprintf("/students/%d", studentID), fmt.Sprintf("/courses/%d", courseID)} {
+		res = doRequest(t, handler, http.MethodDelete, url, "")
+		if res.Code != http.StatusOK {
+			t.Fatalf("delete %s: expected 200, got %d: %s", url, res.Code, res.Body.String())
+		}
+	}
+
+	res = doRequest(t, handler, http.MethodGet, "/certificates/"+certificate.Code, "")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "certified student") {
+		t.Errorf("expected the certificate to verify after deleting, got %d: %s", res.Code, res.Body.String())
+	}
+}
+
+func TestDeleteStudentWithEnrollments(t *testing.T) {
+	handler := newTestHandler()
+	studentID := createTestStudent(t, "enrolled deleted student")
+	studentURL := fmt.Sprintf("/students/%d", studentID)
+
+	for i := 0; i < 2; i++ {
+		courseID := createTestCourse(t, fmt.Sprintf("deleted student course %d", i))
+		res := doRequest(t, handler, http.MethodPost, enrollURL(studentID, courseID), "")
+		if res.Code != http.StatusOK {
+			t.Fatalf("enroll: expected 200, got %d: %s", res.Code, res.Body.String())
+		}
+	}
+
+	res := doRequest(t, handler, http.MethodDelete, studentURL, "")
+	if enrollments := conflictEnrollments(t, res); enrollments != 2 {
+		t.Errorf("expected 2 enrollments in the conflict, got %d", enrollments)
+	}
+
+	if count := countEnrollments(t, "student_id", studentID); count != 2 {
+		t.Fatalf("rejected delete changed the enrollments, got %d", count)
+	}
+
+	res = doRequest(t, handler, http.MethodDelete, studentURL+"?cascade=true", "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("cascade delete: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	if count := countEnrollments(t, "student_id", studentID); count != 0 {
+		t.Errorf("expected the enrollments to be dropped, got %d", count)
+	}
+
+	res = doRequest(t, handler, http.MethodGet, studentURL, "")
+	if res.Code != http.StatusNotFound {
+		t.Errorf("deleted student: expected 404, got %d", res.Code)
+	}
+}
+
+func TestStudentRejectsInvalidCPF(t *testing.T) {
+	handler := newTestHandler()
+	studentID := createTestStudent(t, "invalid cpf student")
+
+	cpfs := []string{
+		"123.456.789-00", // wrong check digits
+		"111.111.111-11", // repeated digit passes the check digits
+		"1234567890",     // too short
+		"123.456.789-0a",
+	}
+
+	for _, cpf := range cpfs {
+		body := fmt.Sprintf(`{"name": "invalid cpf student", "cpf": %q}`, cpf)
+
+		res := doRequest(t, handler, http.MethodPost, "/students", body)
+		if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), usecase.ErrInvalidCPF.Error()) {
+			t.Errorf("create with cpf %q: expected 400, got %d: %s", cpf, res.Code, res.Body.String())
+		}
+
+		res = doRequest(t, handler, http.MethodPut, fmt.Sprintf("/students/%d", studentID), body)
+		if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), usecase.ErrInvalidCPF.Error()) {
+			t.Errorf("update with cpf %q: expected 400, got %d: %s", cpf, res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestStudentProfileIsNormalized(t *testing.T) {
+	handler := newTestHandler()
+
+	body := `{"name": "normalized student", "email": "  Normalized@Example.COM ", "cpf": "529.982.247-25"}`
+	res := doRequest(t, handler, http.MethodPost, "/students", body)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var email, cpf string
+	err := testDB.QueryRow(`SELECT email, cpf FROM student WHERE name = 'normalized student'`).Scan(&email, &cpf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if email != "normalized@example.com" {
+		t.Errorf("expected email to be stored as normalized@example.com, got %q", email)
+	}
+	if cpf != "52998224725" {
+		t.Errorf("expected cpf to be stored as 52998224725, got %q", cpf)
+	}
+}
+
+func TestDuplicateStudentProfileConflicts(t *testing.T) {
+	handler := newTestHandler()
+
+	body := `{"name": "original student", "email": "taken@example.com", "cpf": "123.456.789-09"}`
+	res := doRequest(t, handler, http.MethodPost, "/students", body)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", res.Code, res.Body.String())
+	}
+
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"email with another case", `{"name": "duplicate student", "email": "Taken@Example.com"}`, "email"},
+		{"unformatted cpf", `{"name": "duplicate student", "cpf": "12345678909"}`, "cpf"},
+	}
+
+	for _, tt := range tests {
+		res := doRequest(t, handler, http.MethodPost, "/students", tt.body)
+		if res.Code != http.StatusConflict {
+			t.Errorf("%s: expected 409, got %d: %s", tt.name, res.Code, res.Body.String())
+			continue
+		}
+
+		var conflict struct {
+			Field string `json:"field"`
+		}
+		if err := json.Unmarshal(res.Body.Bytes(), &conflict); err != nil {
+			t.Fatal(err)
+		}
+		if conflict.Field != tt.field {
+			t.Errorf("%s: expected field %q, got %q", tt.name, tt.field, conflict.Field)
+		}
+	}
+}
+
+func TestEnrollmentLimitRequiresAdmin(t *testing.T) {
+	handler := newTestHandler()
+	studentID := createTestStudent(t, "limited student")
+	url := fmt.Sprintf("/admin/students/%d/enrollment-limit", studentID)
+
+	res := doRequest(t, handler, http.MethodPut, url, `{"max_courses": 10}`)
+	if res.Code != http.StatusForbidden {
+		t.Errorf("anonymous set: expected 403, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequest(t, handler, http.MethodDelete, url, "")
+	if res.Code != http.StatusForbidden {
+		t.Errorf("anonymous clear: expected 403, got %d: %s", res.Code, res.Body.String())
+	}
+
+	var maxCourses sql.NullInt64
+	if err := testDB.QueryRow(`SELECT max_courses FROM student WHERE id = $1`, studentID).Scan(&maxCourses); err != nil {
+		t.Fatal(err)
+	}
+	if maxCourses.Valid {
+		t.Fatalf("anonymous request changed the limit to %d", maxCourses.Int64)
+	}
+
+	res = doRequestAs(t, handler, testAdminToken, http.MethodPut, url, `{"max_courses": 10}`)
+	if res.Code != http.StatusOK {
+		t.Errorf("admin set: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doRequestAs(t, handler, testAdminToken, http.MethodDelete, url, "")
+	if res.Code != http.StatusOK {
+		t.Errorf("admin clear: expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+}
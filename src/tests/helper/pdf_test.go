@@ -0,0 +1,90 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/felipedavid/vrcursos/src/core/helper"
+)
+
+func TestWritePDFXrefPointsToObjects(t *testing.T) {
+	var pdf bytes.Buffer
+	err := helper.WritePDF(&pdf, 842, 595, []helper.PDFText{
+		{Text: "Certificate of Completion", Size: 32, Bold: true, Y: 450},
+		{Text: "João (da) Silva \\ Conceição", Size: 28, Y: 340},
+	})
+	if err != nil {
+		t.Fatalf("write pdf: %v", err)
+	}
+	doc := pdf.String()
+
+	start := strings.LastIndex(doc, "startxref\n")
+	if start < 0 {
+		t.Fatal("startxref not found")
+	}
+	xref, err := strconv.Atoi(strings.Fields(doc[start+len("startxref\n"):])[0])
+	if err != nil {
+		t.Fatalf("parse startxref: %v", err)
+	}
+	if !strings.HasPrefix(doc[xref:], "xref\n") {
+		t.Fatalf("startxref %d doesn't point to the xref table", xref)
+	}
+
+	lines := strings.Split(doc[xref:], "\n")
+	var first, count int
+	if _, err := fmt.Sscanf(lines[1], "%d %d", &first, &count); err != nil {
+		t.Fatalf("parse xref subsection %q: %v", lines[1], err)
+	}
+	if first != 0 || count != 7 {
+		t.Fatalf("expected 7 entries starting at 0, got %d starting at %d", count, first)
+	}
+
+	// Every entry must be exactly 20 bytes, including the line break
+	for i := 0; i < count; i++ {
+		if len(lines[2+i])+1 != 20 {
+			t.Fatalf("entry %d is %d bytes long", i, len(lines[2+i])+1)
+		}
+	}
+
+	for object := 1; object < count; object++ {
+		offset, err := strconv.Atoi(lines[2+object][:10])
+		if err != nil {
+			t.Fatalf("parse offset of object %d: %v", object, err)
+		}
+
+		header := fmt.Sprintf("%d 0 obj\n", object)
+		if !strings.HasPrefix(doc[offset:], header) {
+			t.Errorf("offset %d of object %d points to %q", offset, object, doc[offset:offset+len(header)])
+		}
+	}
+
+	if !strings.Contains(doc, fmt.Sprintf("/Size %d", count)) {
+		t.Errorf("trailer size doesn't match the %d xref entries", count)
+	}
+}
+
+func TestWritePDFStreamLengthMatchesContent(t *testing.T) {
+	var pdf bytes.Buffer
+	err := helper.WritePDF(&pdf, 842, 595, []helper.PDFText{
+		{Text: "Ação", Size: 12, Y: 100},
+	})
+	if err != nil {
+		t.Fatalf("write pdf: %v", err)
+	}
+	doc := pdf.String()
+
+	var length int
+	i := strings.Index(doc, "<< /Length ")
+	if _, err := fmt.Sscanf(doc[i:], "<< /Length %d >>", &length); err != nil {
+		t.Fatalf("parse stream length: %v", err)
+	}
+
+	begin := strings.Index(doc, "stream\n") + len("stream\n")
+	end := strings.Index(doc, "endstream")
+	if end-begin != length {
+		t.Errorf("stream is %d bytes long, /Length says %d", end-begin, length)
+	}
+}
@@ -0,0 +1,37 @@
+package payment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/felipedavid/vrcursos/src/core/model"
+	"github.com/felipedavid/vrcursos/src/infrastructure/payment/fake"
+)
+
+func TestFakeGatewayCreateChargeIsIdempotent(t *testing.T) {
+	gateway := fake.NewFakeGateway("http://localhost", []byte("secret"))
+	invoice := &model.Invoice{ID: 1}
+
+	first, err := gateway.CreateCharge(context.Background(), invoice, "payment-task-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retried, err := gateway.CreateCharge(context.Background(), invoice, "payment-task-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if retried.Reference != first.Reference || retried.PaymentURL != first.PaymentURL {
+		t.Errorf("retried call created another charge: %+v and %+v", first, retried)
+	}
+
+	other, err := gateway.CreateCharge(context.Background(), invoice, "payment-task-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other.Reference == first.Reference {
+		t.Errorf("different keys got the same charge %q", first.Reference)
+	}
+}